@@ -16,20 +16,133 @@ package main
 
 import (
 	"bufio"
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"net/http"
 	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"sort"
+	"strconv"
 	"strings"
+	"sync"
+	"syscall"
 	"text/template"
+	"time"
+	"unicode"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/language"
 )
 
-const codeTemplate string = `// tslint:disable
+const codeTemplate string = `
+{{- if not .NoBanner -}}
+// tslint:disable
 /* Code generated by openapi-gen/main.go. DO NOT EDIT. */
+{{- if .Info.Title }}
+/* Generated from {{ .Info.Title }} version {{ .Info.Version }}. */
+{{- end }}
+{{- end }}
+
+{{- /* each import is separated from what precedes it by $sep. */}}
+{{- $sep := "" }}
+{{- if not .NoBanner }}{{ $sep = "\n\n" }}{{ end }}
+{{- if not .InlineUtils }}{{ $sep }}import { buildFetchOptions } from './utils';{{ $sep = "\n" }}{{ end }}
+{{- if not .DefinitionsOnly }}{{ $sep }}import { encode } from 'js-base64';{{ $sep = "\n" }}{{ end }}
+{{- if and .EmitZod (not .ApiOnly) }}{{ $sep }}import { z } from 'zod';{{ $sep = "\n" }}{{ end }}
+{{- if and .EmitIoTs (not .ApiOnly) }}{{ $sep }}import * as t from 'io-ts';{{ $sep = "\n" }}{{ end }}
+{{- if and .EmitReactHooks (not .DefinitionsOnly) }}{{ $sep }}import { useEffect, useState } from 'react';{{ $sep = "\n" }}{{ end }}
+{{- if and .EmitVueComposables (not .DefinitionsOnly) }}{{ $sep }}import { onUnmounted, readonly, ref } from '@vue/runtime-core';
+import type { Ref } from '@vue/runtime-core';{{ $sep = "\n" }}{{ end }}
+{{- if and .EmitAngular (not .DefinitionsOnly) }}{{ $sep }}import { Inject, Injectable, InjectionToken } from '@angular/core';{{ $sep = "\n" }}{{ end }}
+{{- if and (or .EmitRxjs .EmitAngular) (not .DefinitionsOnly) }}{{ $sep }}import { Observable, from } from 'rxjs';{{ $sep = "\n" }}{{ end }}
+
+{{- $sse := false }}
+{{- $blob := false }}
+{{- $progress := false }}
+{{- range $url, $path := .Paths }}
+  {{- range $method, $operation := $path }}
+    {{- if $operation.XNakamaSse }}{{ $sse = true }}{{ end }}
+    {{- if eq (responseType $operation.Produces) "blob" }}{{ $blob = true }}{{ $progress = true }}{{ end }}
+    {{- if uploadsFile $operation }}{{ $progress = true }}{{ end }}
+  {{- end }}
+{{- end }}
+{{- if .TsNamespace }}
+
+export namespace {{ .TsNamespace }} {
+{{- end }}
+{{- if not .ApiOnly }}
+
+/** The version of the API specification this client was generated from. */
+export const SDK_VERSION = "{{ .Info.Version }}";
+
+{{- if .InlineUtils }}
+
+/** Build the fetch options of a request with JSON default headers. */
+export function buildFetchOptions(method: string, options: any, bodyJson: string) {
+  const fetchOptions = {...{ method: method }, ...options};
+  fetchOptions.headers = {...options.headers};
+
+  // in Cocos Creator, XMLHttpRequest.withCredentials is not writable, so make
+  // the fetch polyfill avoid writing to it.
+  const descriptor = typeof XMLHttpRequest !== "undefined"
+    ? Object.getOwnPropertyDescriptor(XMLHttpRequest.prototype, "withCredentials")
+    : undefined;
+  if (descriptor && !descriptor.set) {
+    fetchOptions.credentials = "cocos-ignore";
+  }
+
+  if (!Object.keys(fetchOptions.headers).includes("Accept")) {
+    fetchOptions.headers["Accept"] = "application/json";
+  }
+  if (!Object.keys(fetchOptions.headers).includes("Content-Type")) {
+    fetchOptions.headers["Content-Type"] = "application/json";
+  }
+  Object.keys(fetchOptions.headers).forEach((key: string) => {
+    if (!fetchOptions.headers[key]) {
+      delete fetchOptions.headers[key];
+    }
+  });
+
+  if (bodyJson) {
+    fetchOptions.body = bodyJson;
+  }
+  return fetchOptions;
+}
+{{- end }}
+
+{{- if hasBytes }}
+
+/** Decode a base64 encoded "byte" format field into raw bytes. */
+export function base64ToUint8Array(value: string): Uint8Array {
+  const binary = atob(value);
+  const bytes = new Uint8Array(binary.length);
+  for (let i = 0; i < binary.length; i++) {
+    bytes[i] = binary.charCodeAt(i);
+  }
+  return bytes;
+}
+{{- end }}
 
-import { buildFetchOptions } from './utils';
-import { encode } from 'js-base64';
+{{- range $enum := integerEnums .Definitions }}
+
+/** The values of the {{ $enum.Name }} fields. */
+export {{ if not $.NoConstEnum }}const {{ end }}enum {{ $enum.Name }} {
+    {{- range $idx, $name := $enum.Names }}
+  {{ $name }} = {{ index $enum.Values $idx }},
+    {{- end }}
+}
+{{- end }}
 
 {{- range $classname, $definition := .Definitions}}
     {{- if isRefToEnum $classname }}
@@ -37,105 +150,530 @@ import { encode } from 'js-base64';
 /**
 * {{ enumSummary $definition }}
 */
-export enum {{ $classname | title }}
+export enum {{ $classname | cleanRef }}
 {
         {{- range $idx, $enum := $definition.Enum }}
-    /* {{ (index (enumDescriptions $definition) $idx) }} */
-    {{ $enum }} = {{ $idx }},
+  /* {{ (index (enumDescriptions $definition) $idx) }} */
+  {{ $enum }} = {{ $idx }},
         {{- end }}
 }
     {{- else }}
 
 /** {{$definition.Description}} */
-export interface {{$classname | title}} {
+export interface {{$classname | cleanRef}} {
           {{- range $key, $property := $definition.Properties}}
               {{- $fieldname := camelToSnake $key }}
+              {{- $optional := "?" }}
+              {{- if and $.Strict (isRequired $definition $key) }}{{ $optional = "" }}{{ end }}
+              {{- $readonly := "" }}
+              {{- if $property.ReadOnly }}{{ $readonly = "readonly " }}{{ end }}
   // {{- replace $property.Description "\n" " "}}
-              {{- if eq $property.Type "integer"}}
-  {{$fieldname}}?: number;
+              {{- if $property.Deprecated }}
+  // @deprecated
+              {{- end }}
+              {{- if $property.WriteOnly }}
+  // write-only: not returned by server
+              {{- end }}
+              {{- with constraintTags $property.Constraints }}
+  /** {{ . }} */
+              {{- end }}
+              {{- if $property.XTsType }}
+  {{$readonly}}{{$fieldname}}{{$optional}}: {{$property.XTsType}};
+              {{- else if integerEnumName $key $property }}
+  {{$readonly}}{{$fieldname}}{{$optional}}: {{ integerEnumName $key $property }};
+              {{- else if and (eq $property.Type "integer") (eq $property.Format "int64") $.Bigint}}
+  {{$readonly}}{{$fieldname}}{{$optional}}: bigint;
+              {{- else if eq $property.Type "integer"}}
+  {{$readonly}}{{$fieldname}}{{$optional}}: number;
               {{- else if eq $property.Type "number" }}
-  {{$fieldname}}?: number;
+  {{$readonly}}{{$fieldname}}{{$optional}}: number;
               {{- else if eq $property.Type "boolean"}}
-  {{$fieldname}}?: boolean;
+  {{$readonly}}{{$fieldname}}{{$optional}}: boolean;
               {{- else if eq $property.Type "array"}}
                 {{- if eq $property.Items.Type "string"}}
-  {{$fieldname}}?: Array<string>;
-                {{- else if eq $property.Items.Type "integer"}}
-  {{$fieldname}}?: Array<number>;
+  {{$readonly}}{{$fieldname}}{{$optional}}: Array<string>;
+                {{- else if or (eq $property.Items.Type "integer") (eq $property.Items.Type "number")}}
+  {{$readonly}}{{$fieldname}}{{$optional}}: Array<number>;
                 {{- else if eq $property.Items.Type "boolean"}}
-  {{$fieldname}}?: Array<boolean>;
+  {{$readonly}}{{$fieldname}}{{$optional}}: Array<boolean>;
                 {{- else}}
-  {{$fieldname}}?: Array<{{$property.Items.Ref | cleanRef}}>;
+  {{$readonly}}{{$fieldname}}{{$optional}}: Array<{{$property.Items.Ref | cleanRef}}>;
                 {{- end}}
               {{- else if eq $property.Type "object"}}
                 {{- if eq $property.AdditionalProperties.Type "string"}}
-  {{$fieldname}}?: Record<string, string>;
-                {{- else if eq $property.AdditionalProperties.Type "integer"}}
-  {{$fieldname}}?: Record<string, integer>;
+  {{$readonly}}{{$fieldname}}{{$optional}}: Record<string, string>;
+                {{- else if or (eq $property.AdditionalProperties.Type "integer") (eq $property.AdditionalProperties.Type "number")}}
+  {{$readonly}}{{$fieldname}}{{$optional}}: Record<string, number>;
                 {{- else if eq $property.AdditionalProperties.Type "boolean"}}
-  {{$fieldname}}?: Record<string, boolean>;
+  {{$readonly}}{{$fieldname}}{{$optional}}: Record<string, boolean>;
                 {{- else }}
-  {{$fieldname}}?: Record<{{$property.AdditionalProperties | cleanRef}}>;
+  {{$readonly}}{{$fieldname}}{{$optional}}: Record<{{$property.AdditionalProperties | cleanRef}}>;
                 {{- end}}
+              {{- else if and (eq $property.Type "string") (or (eq $property.Format "date-time") (eq $property.Format "date")) $.DateReviver}}
+  {{$readonly}}{{$fieldname}}{{$optional}}: Date;
+              {{- else if and (eq $property.Type "string") (eq $property.Format "byte")}}
+  /** Base64 encoded on the wire, see base64ToUint8Array. */
+  {{$readonly}}{{$fieldname}}{{$optional}}: Uint8Array;
+              {{- else if and (eq $property.Type "string") (eq $property.Format "binary")}}
+  /** Raw binary content. */
+  {{$readonly}}{{$fieldname}}{{$optional}}: Uint8Array;
               {{- else if eq $property.Type "string"}}
-  {{$fieldname}}?: string;
+  {{$readonly}}{{$fieldname}}{{$optional}}: string;
               {{- else}}
-  {{$fieldname}}?: {{$property.Ref | cleanRef}};
+  {{$readonly}}{{$fieldname}}{{$optional}}: {{$property.Ref | cleanRef}};
               {{- end}}
           {{- end}}
+          {{- if $definition.AllowsAdditionalProperties }}
+  [key: string]: any;
+          {{- end }}
 }
+          {{- if and $.EmitDefaults $definition.HasDefaults }}
+
+/** The default values of the {{$classname | cleanRef}} fields which have one. */
+export const default{{$classname | cleanRef}}: Partial<{{$classname | cleanRef}}> = {
+            {{- range $key, $property := $definition.Properties}}
+              {{- with defaultValue $property }}
+  {{ camelToSnake $key }}: {{ . }},
+              {{- end }}
+            {{- end }}
+};
+          {{- end }}
     {{- end}}
 {{- end }}
 
-export class {{ .Namespace }}Api {
+{{- with jsonConversions }}
+
+// The fields of each definition whose JSON values convertJson converts, by the definition name.
+const jsonConversions: Record<string, Record<string, string>> = {
+  {{- range $name, $fields := . }}
+  "{{ $name }}": {
+    {{- range $key, $conversion := $fields }}
+    "{{ $key }}": "{{ $conversion }}",
+    {{- end }}
+  },
+  {{- end }}
+};
+
+/**
+ * Convert the fields of a parsed JSON value of the named definition to their TypeScript types, in place. Fields which
+ * were converted already are left alone.
+ */
+export function convertJson(value: any, type: string): any {
+  if (value === null || value === undefined) {
+    return value;
+  }
+  if (type.startsWith("[]")) {
+    return Array.isArray(value) ? value.map((item: any) => convertJson(item, type.slice(2))) : value;
+  }
+  {{- if $.DateReviver }}
+  if (type == "date") {
+    return typeof value === "string" ? new Date(value) : value;
+  }
+  {{- end }}
+  {{- if bigints }}
+  if (type == "bigint") {
+    // the server sends int64 values as strings, so they keep their precision.
+    return typeof value === "string" || typeof value === "number" ? BigInt(value) : value;
+  }
+  {{- end }}
+  {{- if hasBytes }}
+  if (type == "bytes") {
+    return typeof value === "string" ? base64ToUint8Array(value) : value;
+  }
+  {{- end }}
+  const fields = jsonConversions[type];
+  if (fields && typeof value === "object") {
+    Object.keys(fields).forEach((key: string) => {
+      if (key in value) {
+        value[key] = convertJson(value[key], fields[key]);
+      }
+    });
+  }
+  return value;
+}
+{{- end }}
+{{- if or bigints hasBytes }}
+
+/**
+ * Serialize the values of a request body the way the server sends them: bigint values as strings and Uint8Array
+ * values base64 encoded.
+ */
+export function jsonReplacer(_key: string, value: any): any {
+  {{- if bigints }}
+  if (typeof value === "bigint") {
+    return value.toString();
+  }
+  {{- end }}
+  {{- if hasBytes }}
+  if (value instanceof Uint8Array) {
+    let binary = "";
+    for (let i = 0; i < value.length; i++) {
+      binary += String.fromCharCode(value[i]);
+    }
+    return btoa(binary);
+  }
+  {{- end }}
+  return value;
+}
+{{- end }}
+
+{{- if .EmitZod }}
+{{- range $classname, $definition := .Definitions }}
+    {{- if isRefToEnum $classname }}
+
+/** Validates {{ $classname | cleanRef }} values at runtime. */
+export const {{ $classname | cleanRef }}Schema = z.nativeEnum({{ $classname | cleanRef }});
+    {{- else }}
+
+/** Validates {{ $classname | cleanRef }} values at runtime. */
+export const {{ $classname | cleanRef }}Schema = z.object({
+          {{- range $key, $property := $definition.Properties }}
+  {{ camelToSnake $key }}: {{ zodType $property }}
+            {{- if $property.XNullable }}.nullable(){{ end }}
+            {{- if not (and $.Strict (isRequired $definition $key)) }}.optional(){{ end }},
+          {{- end }}
+}){{ if $definition.AllowsAdditionalProperties }}.passthrough(){{ end }};
+    {{- end }}
+{{- end }}
+{{- if .ValidateResponses }}
+
+/** Thrown when a response does not match the Zod schema of its definition. */
+export class {{ .Namespace }}ValidationError extends Error {
+  constructor(readonly issues: z.ZodIssue[]) {
+    super("Invalid response: " + issues.map((issue) => issue.path.join(".") + ": " + issue.message).join(", "));
+    this.name = "{{ .Namespace }}ValidationError";
+  }
+}
+
+/** Parse a response with the schema of its definition, or throw a {{ .Namespace }}ValidationError. */
+export function validateResponse(schema: z.ZodTypeAny, body: unknown): any {
+  const result = schema.safeParse(body);
+  if (!result.success) {
+    throw new {{ .Namespace }}ValidationError(result.error.issues);
+  }
+  return result.data;
+}
+{{- end }}
+{{- end }}
+
+{{- if .EmitIoTs }}
+{{- if .DateReviver }}
+
+/** Decodes ISO 8601 date strings into Date values. */
+export const DateFromStringCodec = new t.Type<Date, string, unknown>(
+  "DateFromString",
+  (u): u is Date => u instanceof Date,
+  (u, c) => {
+    const date = typeof u === "string" ? new Date(u) : u;
+    return date instanceof Date && !isNaN(date.getTime()) ? t.success(date) : t.failure(u, c);
+  },
+  (a) => a.toISOString(),
+);
+{{- end }}
+{{- if bigints }}
+
+/** Decodes int64 values, which the server sends as strings, into bigint values. */
+export const BigIntFromStringCodec = new t.Type<bigint, string, unknown>(
+  "BigIntFromString",
+  (u): u is bigint => typeof u === "bigint",
+  (u, c) => {
+    try {
+      return typeof u === "bigint" || typeof u === "string" || typeof u === "number" ? t.success(BigInt(u)) : t.failure(u, c);
+    } catch (e) {
+      return t.failure(u, c);
+    }
+  },
+  (a) => a.toString(),
+);
+{{- end }}
+{{- if hasBytes }}
+
+/** Decodes base64 encoded strings into Uint8Array values. */
+export const Uint8ArrayFromBase64Codec = new t.Type<Uint8Array, string, unknown>(
+  "Uint8ArrayFromBase64",
+  (u): u is Uint8Array => u instanceof Uint8Array,
+  (u, c) => {
+    if (u instanceof Uint8Array) {
+      return t.success(u);
+    }
+    try {
+      return typeof u === "string" ? t.success(base64ToUint8Array(u)) : t.failure(u, c);
+    } catch (e) {
+      return t.failure(u, c);
+    }
+  },
+  (a) => jsonReplacer("", a),
+);
+{{- end }}
+{{- range $classname := ioTsOrder .Definitions }}
+
+/** Decodes {{ $classname | cleanRef }} values at runtime. */
+export const {{ $classname | cleanRef }}Codec
+  {{- if ioTsRecursive $classname }}: t.Type<{{ $classname | cleanRef }}, any> = t.recursion("{{ $classname | cleanRef }}", () => {{ ioTsCodec (index $.Definitions $classname) }})
+  {{- else }} = {{ ioTsCodec (index $.Definitions $classname) }}
+  {{- end }};
+{{- end }}
+{{- end }}
+
+{{- if .EmitTypeGuards }}
+{{- range $classname, $definition := .Definitions }}
+    {{- if not (isRefToEnum $classname) }}
+
+/** Reports whether a value has the required fields of {{ $classname | cleanRef }}. */
+export function is{{ $classname | cleanRef }}(obj: any): obj is {{ $classname | cleanRef }} {
+  return typeof obj === "object" && obj !== null
+          {{- range $key := $definition.Required }}
+    && Object.prototype.hasOwnProperty.call(obj, "{{ camelToSnake $key }}")
+            {{- with guardCheck (index $definition.Properties $key) (camelToSnake $key) }} && {{ . }}{{ end }}
+          {{- end }};
+}
+    {{- end }}
+{{- end }}
+{{- end }}
+
+{{- if $sse }}
+
+/**
+ * A server-sent events stream with typed messages. EventSource cannot send an
+ * Authorization header, so streams authenticate with cookies sent when
+ * withCredentials is set.
+ */
+export class EventStream<T> {
+  private source: EventSource | null = null;
+
+  onmessage: (message: T) => void = () => {};
+  onError: (err: Event) => void = () => {};
+
+  constructor(readonly url: string, readonly init: EventSourceInit = {}) {
+    this.reconnect();
+  }
+
+  /** Close the current connection, if any, and open a new one. */
+  reconnect() {
+    this.close();
+
+    const source = new EventSource(this.url, this.init);
+    source.onmessage = (event: MessageEvent) => {
+      this.onmessage(JSON.parse(event.data));
+    };
+    source.onerror = (err: Event) => {
+      this.onError(err);
+    };
+    this.source = source;
+  }
+
+  /** Close the connection. */
+  close() {
+    if (this.source) {
+      this.source.close();
+      this.source = null;
+    }
+  }
+}
+{{- end }}
+
+/** Optional behaviour of the API client. */
+export interface ConfigurationParameters {
+  // The number of times a failed request is retried.
+  retries?: number;
+  // Only retry GET, HEAD, PUT and DELETE requests, and POST requests marked idempotent.
+  retryIdempotentOnly?: boolean;
+  // Retry after a random delay of up to this many milliseconds, doubled for each attempt.
+  retryBaseDelayMs?: number;
+  // The longest delay before a retry.
+  retryMaxDelayMs?: number;
+  // Sign each request with this key, for API gateways which require it.
+  signingKey?: string;
+  // The algorithm requests are signed with.
+  signingAlgorithm?: "hmac-sha256";
+  // Stop sending requests after this many consecutive server errors, timeouts or network failures. 0 disables the circuit breaker.
+  circuitBreakerThreshold?: number;
+  // How long the circuit breaker stays open before a single request probes whether the server recovered.
+  circuitBreakerResetMs?: number;
+{{- if .EmitOtel }}
+  // Trace each request in a span named after its operation.
+  tracer?: { startSpan(name: string): Span } | null;
+{{- end }}
+}
+{{- if .EmitOtel }}
+
+/** The part of an OpenTelemetry span used by the API client. */
+export interface Span {
+  spanContext(): { traceId: string; spanId: string; traceFlags: number };
+  setAttribute(key: string, value: string | number | boolean): any;
+  setStatus(status: { code: number; message?: string }): any;
+  end(): void;
+}
+{{- end }}
+
+
+/** The configuration used for the parameters which are not given. */
+{{ if or .EmitDefaults .DefinitionsOnly }}export {{ end }}const defaultConfiguration: Required<ConfigurationParameters> = {
+  retries: 0,
+  retryIdempotentOnly: false,
+  retryBaseDelayMs: 100,
+  retryMaxDelayMs: 10000,
+  signingKey: "",
+  signingAlgorithm: "hmac-sha256",
+  circuitBreakerThreshold: 0,
+  circuitBreakerResetMs: 30000,
+{{- if .EmitOtel }}
+  tracer: null,
+{{- end }}
+};
+{{- if $progress }}
+
+/** Options accepted by operations which transfer binary content. */
+export interface TransferProgressOptions {
+  // Called as the request body is sent.
+  onUploadProgress?: (loaded: number, total: number) => void;
+  // Called as the response body is received.
+  onDownloadProgress?: (loaded: number, total: number) => void;
+  [option: string]: any;
+}
+{{- end }}
+{{- if $blob }}
+
+/** Options accepted by operations which download binary content. */
+export interface BlobDownloadOptions extends TransferProgressOptions {
+  // A file name hint for downloads saved through URL.createObjectURL.
+  filename?: string;
+}
+{{- end }}
+{{- if and .EmitOfflineQueue (offlineQueued .Paths) }}
+
+/** A call to an offline-safe operation waiting in an offline queue. */
+export interface {{ .Prefix }}{{ .Namespace }}QueuedRequest {
+  method: string;
+  // The arguments of the call, without its session token.
+  args: any[];
+  // Whether the call is sent with a session token, which is fetched when it is sent.
+  bearer: boolean;
+  attempts: number;
+}
+{{- end }}
+{{- end }}
+{{- if not .DefinitionsOnly }}
+
+const clone = typeof structuredClone !== 'undefined' ? structuredClone : (o: any) => JSON.parse(JSON.stringify(o));
+
+export class {{ .Prefix }}{{ .Namespace }}{{ .ApiSuffix }}Api {
+
+{{- if .Strict }}
+
+  readonly configuration: Required<ConfigurationParameters>;
+
+  constructor(readonly{{- if eq .Namespace "Nakama" }} serverKey{{- end }}{{- if eq .Namespace "Satori" }} apiKey{{- end }}: string, readonly basePath: string, readonly timeoutMs: number, configuration: ConfigurationParameters = {}) {
+    this.configuration = {...defaultConfiguration, ...configuration};
+  }
+{{- else }}
+
+  constructor(readonly{{- if eq .Namespace "Nakama" }} serverKey{{- end }}{{- if eq .Namespace "Satori" }} apiKey{{- end }}: string, readonly basePath: string, readonly timeoutMs: number, readonly configuration: ConfigurationParameters = {}) {}
+{{- end }}
+
+  // The state of the circuit breaker, used when configuration.circuitBreakerThreshold is set.
+  readonly circuit = {state: "closed" as "closed" | "open" | "half-open", failures: 0, openedAt: 0};
+{{- if .EmitDedup }}
 
-  constructor(readonly{{- if eq .Namespace "Nakama" }} serverKey{{- end }}{{- if eq .Namespace "Satori" }} apiKey{{- end }}: string, readonly basePath: string, readonly timeoutMs: number) {}
+  // The GET requests in flight by their method, URL and credentials, shared by identical concurrent calls.
+  readonly inflight = new Map<string, Promise<any>>();
+{{- end }}
+{{- if .GroupByTag }}
+{{- range $group := tagGroups .Paths }}
+
+  // The operations whose first tag is {{ $group.Tag }}.
+  readonly {{ $group.Tag | tagProperty }} = {
+  {{- range $group.Operations }}
+    {{- $name := methodName .Operation }}
+    {{ $name }}: this.{{ $name }}.bind(this),
+  {{- end }}
+  };
+{{- end }}
+{{- end }}
 
 {{- range $url, $path := .Paths}}
   {{- range $method, $operation := $path}}
 
+  {{- $described := false }}
+  {{- range $parameter := $operation.Parameters }}
+    {{- if or $parameter.Description (constraintTags $parameter.Constraints) }}{{ $described = true }}{{ end }}
+  {{- end }}
+
+  {{- $returnType := returnType $operation }}
+  {{- $responseType := responseType $operation.Produces }}
+  {{- $optionsType := "any" }}
+  {{- if eq $responseType "blob" }}{{ $optionsType = "BlobDownloadOptions" }}
+  {{- else if uploadsFile $operation }}{{ $optionsType = "TransferProgressOptions" }}
+  {{- end }}
+  {{- if eq $returnType "void" }}{{ $responseType = "void" }}{{ end }}
+  {{- $dedup := and $.EmitDedup (eq $method "get") }}
+  {{- $convert := "" }}
+  {{- if eq $responseType "json" }}{{ $convert = convertsTo $operation }}{{ end }}
+
+  {{ if or $operation.Deprecated $operation.XDeprecatedReason $described $operation.Responses.Ok.Description -}}
+  /**
+   * {{$operation.Summary}}
+    {{- range $parameter := $operation.Parameters }}
+      {{- $tags := constraintTags $parameter.Constraints }}
+      {{- if or $parameter.Description $tags }}
+   * @param { {{- parameterType $parameter -}} } {{ if and $.EmitRequestTypes (ne $parameter.In "path") }}request.{{ $parameter.Name | snakeToCamel }}{{ else }}{{ $parameter.Name | snakeToCamel | escapeReserved }}{{ end }} - {{ replace $parameter.Description "\n" " " }}
+        {{- if and $parameter.Description $tags }} {{ end }}{{ $tags }}
+      {{- end }}
+    {{- end }}
+    {{- if $operation.Responses.Ok.Description }}
+   * @returns { {{- resultType $operation -}} } {{ replace $operation.Responses.Ok.Description "\n" " " }}
+    {{- end }}
+    {{- if $operation.XDeprecatedReason }}
+   * @deprecated {{ replace $operation.XDeprecatedReason "\n" " " }}
+    {{- else if $operation.Deprecated }}
+   * @deprecated
+    {{- end }}
+   */
+  {{- else -}}
   /** {{$operation.Summary}} */
-  {{ $operation.OperationId | stripOperationPrefix | snakeToCamel }}(
-  {{- if $operation.Security }}
+  {{- end }}
+  {{ if $operation.XNakamaSse }}subscribe{{ $operation.Name | stripOperationPrefix | snakeToCamel | camelToPascal }}{{ else }}{{ $operation.Name | stripOperationPrefix | snakeToCamel | escapeReserved }}{{ end }}(
+  {{- if $operation.XNakamaSse }}
+  {{- else if $operation.Security }}
     {{- range $idx, $security := $operation.Security }}
         {{- range $key, $value := $security }}
           {{- if eq $key "BasicAuth" -}}
     basicAuthUsername: string,
     basicAuthPassword: string,
           {{- else if eq $key "HttpKeyAuth" -}}
-		basicAuthUsername: string,
-		basicAuthPassword: string,
-					{{- else if eq $key "BearerJwt" -}}
-		bearerToken: string,
+    basicAuthUsername: string,
+    basicAuthPassword: string,
+          {{- else if eq $key "BearerJwt" -}}
+    bearerToken: string,
           {{- end }}
         {{- end }}
     {{- end }}
   {{- else -}}
     bearerToken: string,
   {{- end }}
+  {{- $request := requestParameters $operation }}
   {{- range $parameter := $operation.Parameters}}
-      {{ $parameter.Name | snakeToCamel }}{{- if not $parameter.Required }}?{{- end -}}:
-          {{- if eq $parameter.In "path" -}}
-    {{ $parameter.Type }},
-          {{- else if eq $parameter.In "body" -}}
-        {{- if eq $parameter.Schema.Type "string" -}}
-    {{ $parameter.Schema.Type }},
-        {{- else -}}
-    {{ $parameter.Schema.Ref | cleanRef }},
-        {{- end }}
-    {{- else if eq $parameter.Type "array" -}}
-    Array<{{$parameter.Items.Type}}>,
-      {{- else if eq $parameter.Type "object" -}}
-    Map<{{$parameter.AdditionalProperties.Type}}>,
-      {{- else if eq $parameter.Type "integer" -}}
-    number,
-      {{- else -}}
-    {{ $parameter.Type }},
-      {{- end -}}
-  {{- end }}
-      options: any = {}): Promise<{{- if $operation.Responses.Ok.Schema.Ref | cleanRef -}} {{- $operation.Responses.Ok.Schema.Ref | cleanRef -}} {{- else -}} any {{- end}}> {
+    {{- if or (not $request) (eq $parameter.In "path") }}
+      {{ $parameter.Name | snakeToCamel | escapeReserved }}{{- if not $parameter.Required }}?{{- end -}}:
+    {{- parameterType $parameter }},
+    {{- end }}
+  {{- end }}
+  {{- if $request }}
+      request: {{ $.Prefix }}{{ $operation.Name | stripOperationPrefix | snakeToCamel | camelToPascal }}Request
+    {{- if not (requiresRequest $operation) }} = {}{{ end }},
+  {{- end }}
+  {{- if $operation.XNakamaSse }}
+      options: EventSourceInit = {}): EventStream<{{- if $operation.Responses.Ok.Schema.Ref -}} {{- $operation.Responses.Ok.Schema.Ref | cleanRef -}} {{- else -}} any {{- end}}> {
+  {{- else }}
+      options: {{ $optionsType }} = {}): Promise<{{ resultType $operation }}> {
+  {{- end }}
+  {{- if $request }}
+    const { {{ range $i, $parameter := $request }}{{ if $i }}, {{ end }}{{ $parameter.Name | snakeToCamel }}
+      {{- if ne ($parameter.Name | snakeToCamel) ($parameter.Name | snakeToCamel | escapeReserved) }}: {{ $parameter.Name | snakeToCamel | escapeReserved }}{{ end }}
+    {{- end }} } = request;
+  {{- end }}
     {{ range $parameter := $operation.Parameters}}
-    {{- $snakeToCamel := $parameter.Name | snakeToCamel}}
+    {{- $snakeToCamel := $parameter.Name | snakeToCamel | escapeReserved }}
     {{- if $parameter.Required }}
     if ({{$snakeToCamel}} === null || {{$snakeToCamel}} === undefined) {
       throw new Error("'{{$snakeToCamel}}' is a required parameter but is null or undefined.");
@@ -144,7 +682,7 @@ export class {{ .Namespace }}Api {
     {{- end}}
     const urlPath = "{{- $url}}"
     {{- range $parameter := $operation.Parameters}}
-    {{- $snakeToCamel := $parameter.Name | snakeToCamel}}
+    {{- $snakeToCamel := $parameter.Name | snakeToCamel | escapeReserved }}
     {{- if eq $parameter.In "path"}}
         .replace("{{- print "{" $parameter.Name "}"}}", encodeURIComponent(String({{- $snakeToCamel}})))
     {{- end}}
@@ -154,349 +692,5031 @@ export class {{ .Namespace }}Api {
     {{- range $parameter := $operation.Parameters}}
     {{- $camelToSnake := $parameter.Name | camelToSnake}}
     {{- if eq $parameter.In "query"}}
-    queryParams.set("{{$parameter.Name | camelToSnake }}", {{$parameter.Name | snakeToCamel}});
+    queryParams.set("{{$parameter.Name | camelToSnake }}", {{$parameter.Name | snakeToCamel | escapeReserved}});
     {{- end}}
     {{- end}}
+    {{- if $operation.XNakamaSse }}
+
+    return new EventStream<{{- if $operation.Responses.Ok.Schema.Ref -}} {{- $operation.Responses.Ok.Schema.Ref | cleanRef -}} {{- else -}} any {{- end}}>(this.buildFullUrl(this.basePath, urlPath, queryParams), options);
+  }
+    {{- else }}
 
     let bodyJson : string = "";
+    {{- $multipart := "" }}
+    {{- range $parameter := $operation.Parameters}}
+    {{- $snakeToCamel := $parameter.Name | snakeToCamel | escapeReserved }}
+    {{- if $parameter.Multipart }}{{ $multipart = $snakeToCamel }}
+    {{- else if eq $parameter.In "body"}}
+    bodyJson = JSON.stringify({{$snakeToCamel}} || {}{{ if or bigints hasBytes }}, jsonReplacer{{ end }});
+    {{- end}}
+    {{- end}}
+    {{- $formData := false }}
     {{- range $parameter := $operation.Parameters}}
-    {{- $snakeToCamel := $parameter.Name | snakeToCamel}}
-    {{- if eq $parameter.In "body"}}
-    bodyJson = JSON.stringify({{$snakeToCamel}} || {});
+      {{- if eq $parameter.In "formData" }}{{ $formData = true }}{{ end }}
+    {{- end }}
+    {{- if $formData }}
+    const formData = new FormData();
+    {{- range $parameter := $operation.Parameters}}
+    {{- $snakeToCamel := $parameter.Name | snakeToCamel | escapeReserved }}
+    {{- if eq $parameter.In "formData"}}
+    if ({{$snakeToCamel}} !== null && {{$snakeToCamel}} !== undefined) {
+      formData.append("{{$parameter.Name}}", {{- if $parameter.File }} {{$snakeToCamel}}{{- else }} String({{$snakeToCamel}}){{- end }});
+    }
     {{- end}}
     {{- end}}
+    {{- end }}
 
     const fullUrl = this.buildFullUrl(this.basePath, urlPath, queryParams);
     const fetchOptions = buildFetchOptions("{{- $method | uppercase}}", options, bodyJson);
-					{{- if $operation.Security }}
-						{{- range $idx, $security := $operation.Security }}
-							{{- range $key, $value := $security }}
-								{{- if eq $key "BasicAuth" }}
-		if (basicAuthUsername) {
-			fetchOptions.headers["Authorization"] = "Basic " + encode(basicAuthUsername + ":" + basicAuthPassword);
-		}
-								{{- else if eq $key "HttpKeyAuth" }}
-		if (basicAuthUsername) {
-			fetchOptions.headers["Authorization"] = "Basic " + encode(basicAuthUsername + ":" + basicAuthPassword);
-		}
-								{{- else if eq $key "BearerJwt" }}
-		if (bearerToken) {
-				fetchOptions.headers["Authorization"] = "Bearer " + bearerToken;
-		}
-								{{- end }}
-							{{- end }}
-						{{- end }}
-          {{- else }}
+    {{- if $operation.Security }}
+      {{- range $idx, $security := $operation.Security }}
+        {{- range $key, $value := $security }}
+          {{- if eq $key "BasicAuth" }}
+    if (basicAuthUsername) {
+      fetchOptions.headers["Authorization"] = "Basic " + encode(basicAuthUsername + ":" + basicAuthPassword);
+    }
+          {{- else if eq $key "HttpKeyAuth" }}
+    if (basicAuthUsername) {
+      fetchOptions.headers["Authorization"] = "Basic " + encode(basicAuthUsername + ":" + basicAuthPassword);
+    }
+          {{- else if eq $key "BearerJwt" }}
     if (bearerToken) {
-        fetchOptions.headers["Authorization"] = "Bearer " + bearerToken;
+      fetchOptions.headers["Authorization"] = "Bearer " + bearerToken;
     }
           {{- end }}
+        {{- end }}
+      {{- end }}
+    {{- else }}
+    if (bearerToken) {
+      fetchOptions.headers["Authorization"] = "Bearer " + bearerToken;
+    }
+    {{- end }}
+
+    {{- if or $formData $multipart }}
+    fetchOptions.body = {{ if $formData }}formData{{ else }}{{ $multipart }}{{ end }};
+    // the browser sets the multipart boundary itself.
+    delete fetchOptions.headers["Content-Type"];
+    {{- end }}
+
+    {{- if ne $optionsType "any" }}
+
+    if (options.onUploadProgress || options.onDownloadProgress) {
+      return this.doXhr(fullUrl, fetchOptions, options
+      {{- if ne $responseType "json" }}, "{{ $responseType }}"{{ end }})
+      {{- with $convert }}
+        {{- if $.EmitResponseTypes }}
+        .then((response) => ({...response, data: convertJson(response.data, "{{ . }}")}))
+        {{- else }}
+        .then((body) => convertJson(body, "{{ . }}"))
+        {{- end }}
+      {{- end }};
+    }
+    {{- end }}
+
+    return {{ if $dedup }}this.dedup(fullUrl, fetchOptions, () => {{ end }}{{ if $.EmitOtel }}this.traced("{{ $operation.OperationId }}", fetchOptions, () => {{ end }}this.doFetch(fullUrl, fetchOptions, {{ isIdempotent $method $operation.XNakamaIdempotencyKey }}
+    {{- if ne $responseType "json" }}, "{{ $responseType }}"{{ end }}){{ if $.EmitOtel }}){{ end }}
+    {{- if and $.ValidateResponses $operation.Responses.Ok.Schema.Ref (eq $responseType "json") }}
+      {{- if $.EmitResponseTypes }}
+      .then((response) => ({...response, data: validateResponse({{ $operation.Responses.Ok.Schema.Ref | cleanRef }}Schema, response.data)}))
+      {{- else }}
+      .then((body) => validateResponse({{ $operation.Responses.Ok.Schema.Ref | cleanRef }}Schema, body))
+      {{- end }}
+    {{- end }}
+    {{- with $convert }}
+      {{- if $.EmitResponseTypes }}
+      .then((response) => ({...response, data: convertJson(response.data, "{{ . }}")}))
+      {{- else }}
+      .then((body) => convertJson(body, "{{ . }}"))
+      {{- end }}
+    {{- end }}{{ if $dedup }}){{ end }};
+  }
+    {{- end }}
+
+  {{- end}}
+{{- end}}
+
+  doFetch(fullUrl: string, fetchOptions: any, idempotent: boolean, responseType: "json" | "text" | "blob" | "void" = "json", attempt: number = 0): Promise<any> {
+    const threshold = this.configuration.circuitBreakerThreshold ?? defaultConfiguration.circuitBreakerThreshold;
+    if (threshold > 0 && this.circuit.state != "closed") {
+      // requests fail fast while the circuit is open, and while the single probe request is in flight.
+      if (this.circuit.state == "half-open" || Date.now() - this.circuit.openedAt < (this.configuration.circuitBreakerResetMs ?? defaultConfiguration.circuitBreakerResetMs)) {
+        return Promise.reject("Circuit breaker is open.");
+      }
+      this.circuit.state = "half-open";
+    }
+
+    // each attempt gets its own copy of the headers so retries start from the same state.
+    const attemptOptions = {...fetchOptions, headers: clone(fetchOptions.headers || {})};
 
     return Promise.race([
-      fetch(fullUrl, fetchOptions).then((response) => {
-        if (response.status == 204) {
+      this.signRequest(fullUrl, attemptOptions).then(() => fetch(fullUrl, attemptOptions)).then((response) => {
+        if (response.status < 500) {
+          this.circuit.state = "closed";
+          this.circuit.failures = 0;
+        }
+        if (response.status < 200 || response.status >= 300) {
+          // error bodies such as a gateway's HTML page are not always JSON, so fall back to the raw text.
+          return response.clone().json().catch(() => response.text()).then((body: any) => {
+            // reject with the response itself, its body replaced by the parsed one.
+            throw Object.defineProperty(response, "body", {value: body});
+          });
+        {{- if .EmitResponseTypes }}
+        }
+        // resolve with the status and headers alongside the body.
+        const wrap = (data: any) => ({data: data, status: response.status, headers: response.headers});
+        if (responseType == "void") {
+          return wrap(undefined);
+        } else if (responseType == "text") {
+          return response.text().then(wrap);
+        } else if (responseType == "blob") {
+          return response.blob().then(wrap);
+        } else if (response.status == 204) {
+          return wrap(response);
+        } else {
+          return response.json().then(wrap);
+        }
+        {{- else }}
+        } else if (responseType == "void") {
+          return undefined;
+        } else if (responseType == "text") {
+          return response.text();
+        } else if (responseType == "blob") {
+          return response.blob();
+        } else if (response.status == 204) {
           return response;
-        } else if (response.status >= 200 && response.status < 300) {
-          return response.json();
         } else {
-          throw response;
+          return response.json();
         }
+        {{- end }}
       }),
       new Promise((_, reject) =>
         setTimeout(reject, this.timeoutMs, "Request timed out.")
       ),
-    ]);
-}
+    ]).catch((err) => {
+      // client errors are never worth retrying.
+      if (err && typeof err.status === "number" && err.status < 500) {
+        throw err;
+      }
 
-  {{- end}}
-{{- end}}
+      if (threshold > 0 && (this.circuit.state == "half-open" || ++this.circuit.failures >= threshold)) {
+        this.circuit.state = "open";
+        this.circuit.openedAt = Date.now();
+      }
 
-    buildFullUrl(basePath: string, fragment: string, queryParams: Map<string, any>) {
-        let fullPath = basePath + fragment + "?";
-
-        for (let [k, v] of queryParams) {
-            if (v instanceof Array) {
-                fullPath += v.reduce((prev: any, curr: any) => {
-                return prev + encodeURIComponent(k) + "=" + encodeURIComponent(curr) + "&";
-                }, "");
-            } else {
-                if (v != null) {
-                    fullPath += encodeURIComponent(k) + "=" + encodeURIComponent(v) + "&";
-                }
-            }
-        }
+      const retries = this.configuration.retries ?? defaultConfiguration.retries;
+      const retryable = idempotent || !this.configuration.retryIdempotentOnly;
+      if (attempt >= retries || !retryable) {
+        throw err;
+      }
 
-        return fullPath;
+      // full jitter: wait a random time up to the exponentially growing delay, so clients sharing a server do not retry in lockstep.
+      const maxDelay = Math.min(this.configuration.retryMaxDelayMs ?? defaultConfiguration.retryMaxDelayMs, (this.configuration.retryBaseDelayMs ?? defaultConfiguration.retryBaseDelayMs) * Math.pow(2, attempt));
+      return new Promise((resolve) => setTimeout(resolve, Math.random() * maxDelay)).then(() =>
+        this.doFetch(fullUrl, fetchOptions, idempotent, responseType, attempt + 1));
+    });
+  }
+{{- if .EmitDedup }}
+
+  /**
+   * Return the promise of an identical request which is still in flight instead of sending another. Requests are
+   * identical when their method, URL, query parameters in any order and Authorization header are the same. Requests
+   * with an abort signal are always sent, so that aborting one does not reject the calls sharing it.
+   */
+  dedup(fullUrl: string, fetchOptions: any, send: () => Promise<any>): Promise<any> {
+    if (fetchOptions.signal) {
+      return send();
     }
-};
-`
 
-type Definition struct {
-	Properties map[string]struct {
-		Type  string
-		Ref   string   `json:"$ref"` // used with object
-		Items struct { // used with type "array"
-			Type string
-			Ref  string `json:"$ref"`
-		}
-		AdditionalProperties struct {
-			Type string // used with type "map"
-		}
-		Format      string // used with type "boolean"
-		Description string
-	}
-	Enum        []string
-	Description string
-	// used only by enums
-	Title string
-}
+    // a relative basePath, such as "/", is resolved against a placeholder origin.
+    const url = new URL(fullUrl, "http://localhost");
+    url.searchParams.sort();
+    const key = fetchOptions.method + " " + url.toString() + " " + (fetchOptions.headers["Authorization"] || "");
+    const inflight = this.inflight.get(key);
+    if (inflight) {
+      return inflight;
+    }
 
-func snakeToCamel(input string) (snakeToCamel string) {
-	isToUpper := false
-	for k, v := range input {
-		if k == 0 {
-			snakeToCamel = strings.ToLower(string(input[0]))
-		} else {
-			if isToUpper {
-				snakeToCamel += strings.ToUpper(string(v))
-				isToUpper = false
-			} else {
-				if v == '_' {
-					isToUpper = true
-				} else {
-					snakeToCamel += string(v)
-				}
-			}
-		}
-	}
-	return
-}
+    const promise = send().finally(() => this.inflight.delete(key));
+    this.inflight.set(key, promise);
+    return promise;
+  }
+{{- end }}
 
-func enumSummary(def Definition) string {
-	// quirk of swagger generation: if enum doesn't have a title
-	// then the title can be found as the first entry in the split description.
-	if def.Title != "" {
-		return def.Title
-	}
+  /**
+   * Sign a request with the configured signingKey, if any. The X-Signature header holds the hex encoded
+   * HMAC-SHA256 of the method, path and X-Signature-Timestamp header of the request.
+   */
+  async signRequest(fullUrl: string, fetchOptions: any): Promise<void> {
+    const signingKey = this.configuration.signingKey;
+    if (!signingKey) {
+      return;
+    }
 
-	split := strings.Split(def.Description, "\n")
+    const timestamp = String(Math.floor(Date.now() / 1000));
+    const path = new URL(fullUrl, "http://localhost").pathname;
+    const encoder = new TextEncoder();
+    const key = await crypto.subtle.importKey("raw", encoder.encode(signingKey), {name: "HMAC", hash: "SHA-256"}, false, ["sign"]);
+    const signature = await crypto.subtle.sign("HMAC", key, encoder.encode(fetchOptions.method + path + timestamp));
+    fetchOptions.headers["X-Signature"] = Array.from(new Uint8Array(signature), (b) => b.toString(16).padStart(2, "0")).join("");
+    fetchOptions.headers["X-Signature-Timestamp"] = timestamp;
+  }
+{{- if .EmitOtel }}
 
-	if len(split) <= 0 {
-		panic("No newlines in enum description found.")
-	}
+  /**
+   * Send a request in a span named after its operation, when a tracer is configured. The span's context is passed
+   * on in a W3C traceparent header and the span status is set from the HTTP status of the response.
+   */
+  traced(name: string, fetchOptions: any, send: () => Promise<any>): Promise<any> {
+    const tracer = this.configuration.tracer;
+    if (!tracer) {
+      return send();
+    }
 
-	return split[0]
-}
+    const span = tracer.startSpan(name);
+    const context = span.spanContext();
+    const traceFlags = ("0" + context.traceFlags.toString(16)).slice(-2);
+    fetchOptions.headers = {...fetchOptions.headers, traceparent: "00-" + context.traceId + "-" + context.spanId + "-" + traceFlags};
 
-func enumDescriptions(def Definition) (output []string) {
+    return send().then((result) => {
+      // SpanStatusCode.OK
+      span.setStatus({code: 1});
+      span.end();
+      return result;
+    }, (err) => {
+      if (err && typeof err.status === "number") {
+        span.setAttribute("http.response.status_code", err.status);
+      }
+      // SpanStatusCode.ERROR
+      span.setStatus({code: 2, message: err && err.statusText ? err.statusText : String(err)});
+      span.end();
+      throw err;
+    });
+  }
+{{- end }}
+{{- if $progress }}
+{{- $resolve := "resolve" }}
 
-	split := strings.Split(def.Description, "\n")
+  doXhr(fullUrl: string, fetchOptions: any, options: TransferProgressOptions, responseType: "json" | "text" | "blob" | "void" = "json"): Promise<any> {
+    // fetch cannot report progress, so requests with a progress callback use XMLHttpRequest instead.
+    return this.signRequest(fullUrl, fetchOptions).then(() => new Promise((resolve, reject) => {
+      const xhr = new XMLHttpRequest();
+      xhr.open(fetchOptions.method, fullUrl);
+      xhr.timeout = this.timeoutMs;
+      xhr.responseType = responseType == "blob" ? "blob" : "text";
+      Object.keys(fetchOptions.headers || {}).forEach((key: string) => {
+        xhr.setRequestHeader(key, fetchOptions.headers[key]);
+      });
 
-	if len(split) <= 0 {
-		panic("No newlines in enum description found.")
-	}
+      const onUploadProgress = options.onUploadProgress;
+      if (onUploadProgress) {
+        xhr.upload.onprogress = (event: ProgressEvent) => onUploadProgress(event.loaded, event.total);
+      }
+      const onDownloadProgress = options.onDownloadProgress;
+      if (onDownloadProgress) {
+        xhr.onprogress = (event: ProgressEvent) => onDownloadProgress(event.loaded, event.total);
+      }
 
-	if def.Title != "" {
-		return split
-	}
+      xhr.onload = () => {
+        const headers = new Headers();
+        xhr.getAllResponseHeaders().trim().split(/[\r\n]+/).forEach((line: string) => {
+          const index = line.indexOf(":");
+          if (index > 0) {
+            headers.append(line.slice(0, index).trim(), line.slice(index + 1).trim());
+          }
+        });
+        {{- if $.EmitResponseTypes }}{{ $resolve = "resolveData" }}
+        // resolve with the status and headers alongside the body.
+        const resolveData = (data: any) => resolve({data: data, status: xhr.status, headers: headers});
+        {{- end }}
+        if (xhr.status < 200 || xhr.status >= 300) {
+          // reject with a response like doFetch does, its body parsed as JSON when possible.
+          let body: any = xhr.response;
+          if (typeof body === "string") {
+            try {
+              body = JSON.parse(body);
+            } catch (e) {
+              // not JSON, such as a gateway's HTML page.
+            }
+          }
+          const response = new Response(null, {status: xhr.status, statusText: xhr.statusText, headers: headers});
+          reject(Object.defineProperties(response, {body: {value: body}, url: {value: xhr.responseURL}}));
+        } else if (responseType == "void") {
+          {{ $resolve }}(undefined);
+        } else if (responseType == "json") {
+          {{ $resolve }}(xhr.responseText ? JSON.parse(xhr.responseText) : {});
+        } else {
+          {{ $resolve }}(xhr.response);
+        }
+      };
+      xhr.onerror = () => reject(new TypeError("Network request failed."));
+      xhr.ontimeout = () => reject("Request timed out.");
+      xhr.send(fetchOptions.body);
+    }));
+  }
+{{- end }}
 
-	// quirk of swagger generation: if enum doesn't have a title
-	// then the title can be found as the first entry in the split description.
-	// so ignore for individual enum descriptions.
-	return split[2:]
-}
+  buildFullUrl(basePath: string, fragment: string, queryParams: Map<string, any>) {
+    let fullPath = basePath + fragment + "?";
 
-func stripOperationPrefix(input string) string {
-	return strings.Replace(input, "Nakama_", "", 1)
+    for (let [k, v] of queryParams) {
+      if (v instanceof Array) {
+        fullPath += v.reduce((prev: any, curr: any) => {
+          return prev + encodeURIComponent(k) + "=" + encodeURIComponent(curr) + "&";
+        }, "");
+      } else {
+        if (v != null) {
+          fullPath += encodeURIComponent(k) + "=" + encodeURIComponent(v) + "&";
+        }
+      }
+    }
+
+    return fullPath;
+  }
+};
+{{- range $url, $path := .Paths }}
+  {{- range $method, $operation := $path }}
+    {{- $request := requestParameters $operation }}
+    {{- if $request }}
+    {{- $name := $operation.Name | stripOperationPrefix | snakeToCamel | camelToPascal }}
+
+/** The parameters of a {{ $name }} request, other than those in its path. */
+export interface {{ $.Prefix }}{{ $name }}Request {
+      {{- range $parameter := $request }}
+        {{- if $parameter.Description }}
+  // {{ replace $parameter.Description "\n" " " }}
+        {{- end }}
+  {{ $parameter.Name | snakeToCamel }}{{ if not $parameter.Required }}?{{ end }}: {{ parameterType $parameter }};
+      {{- end }}
+}
+    {{- end }}
+  {{- end }}
+{{- end }}
+{{- if .EmitResponseTypes }}
+{{- range $url, $path := .Paths }}
+  {{- range $method, $operation := $path }}
+    {{- if not $operation.XNakamaSse }}
+    {{- $name := $operation.Name | stripOperationPrefix | snakeToCamel | camelToPascal }}
+
+/** The body of a {{ $name }} response together with its status and headers. */
+export interface {{ $.Prefix }}{{ $name }}Response {
+  data: {{ returnType $operation }};
+  status: number;
+  headers: Headers;
+}
+    {{- end }}
+  {{- end }}
+{{- end }}
+{{- end }}
+{{- if .EmitResponseTypes }}
+{{- range $url, $path := .Paths }}
+  {{- range $method, $operation := $path }}
+    {{- if $operation.Responses.Ok.Headers }}
+    {{- $name := $operation.Name | stripOperationPrefix | snakeToCamel | camelToPascal }}
+
+/** The headers of a {{ $name }} response. */
+export interface {{ $.Prefix }}{{ $name }}Headers {
+      {{- range $header, $definition := $operation.Responses.Ok.Headers }}
+        {{- if $definition.Description }}
+  // {{ replace $definition.Description "\n" " " }}
+        {{- end }}
+  {{ headerField $header }}?: {{ headerType $definition }};
+      {{- end }}
+}
+
+/** Read the headers of a {{ $name }} response into their types. */
+export function extractHeaders{{ $name }}(response: { headers: Headers }): {{ $.Prefix }}{{ $name }}Headers {
+  const headers: {{ $.Prefix }}{{ $name }}Headers = {};
+      {{- range $header, $definition := $operation.Responses.Ok.Headers }}
+      {{- $field := headerField $header }}
+  const {{ $field }} = response.headers.get("{{ $header }}");
+  if ({{ $field }} !== null) {
+    headers.{{ $field }} = {{ headerValue $definition $field }};
+  }
+      {{- end }}
+  return headers;
+}
+    {{- end }}
+  {{- end }}
+{{- end }}
+{{- end }}
+{{- if .EmitReactHooks }}
+{{- range $url, $path := .Paths }}
+  {{- range $method, $operation := $path }}
+    {{- if not $operation.XNakamaSse }}
+    {{- $name := $operation.Name | stripOperationPrefix | snakeToCamel }}
+    {{- $arguments := operationArguments $operation }}
+
+/**
+ * Call {{ $name | escapeReserved }} when the component mounts and whenever the arguments change. The request is
+ * aborted when the component unmounts.
+ */
+export function use{{ $name | camelToPascal }}(api: {{ $.Prefix }}{{ $.Namespace }}{{ $.ApiSuffix }}Api{{ range operationParameters $operation }}, {{ . }}{{ end }}, options: any = {}) {
+  const [state, setState] = useState<{ data: {{ resultType $operation }} | null; loading: boolean; error: any }>({ data: null, loading: true, error: null });
+  useEffect(() => {
+    const controller = new AbortController();
+    setState({ data: null, loading: true, error: null });
+    api.{{ $name | escapeReserved }}({{ range $arguments }}{{ . }}, {{ end }}{ ...options, signal: controller.signal }).then(
+      (data) => setState({ data: data, loading: false, error: null }),
+      (error) => {
+        if (!controller.signal.aborted) {
+          setState({ data: null, loading: false, error: error });
+        }
+      });
+    return () => controller.abort();
+  }, [api{{ range $arguments }}, {{ . }}{{ end }}]);
+  return state;
+}
+    {{- end }}
+  {{- end }}
+{{- end }}
+{{- end }}
+{{- if .EmitVueComposables }}
+{{- range $url, $path := .Paths }}
+  {{- range $method, $operation := $path }}
+    {{- if not $operation.XNakamaSse }}
+    {{- $name := $operation.Name | stripOperationPrefix | snakeToCamel }}
+    {{- $returnType := resultType $operation }}
+
+/**
+ * Wrap {{ $name | escapeReserved }} in reactive state. Each call to execute sends the request, aborting the previous
+ * one, and the request is aborted when the component is unmounted.
+ */
+export function use{{ $name | camelToPascal }}(api: {{ $.Prefix }}{{ $.Namespace }}{{ $.ApiSuffix }}Api{{ range operationParameters $operation }}, {{ . }}{{ end }}, options: any = {}): {
+  data: Readonly<Ref<{{ $returnType }} | null>>;
+  loading: Readonly<Ref<boolean>>;
+  error: Readonly<Ref<Error | null>>;
+  execute: () => Promise<void>;
+} {
+  const data = ref(null) as Ref<{{ $returnType }} | null>;
+  const loading = ref(false);
+  const error = ref(null) as Ref<Error | null>;
+  let controller: AbortController | null = null;
+
+  const execute = (): Promise<void> => {
+    if (controller) {
+      controller.abort();
+    }
+    const current = controller = new AbortController();
+    loading.value = true;
+    error.value = null;
+    return api.{{ $name | escapeReserved }}({{ range operationArguments $operation }}{{ . }}, {{ end }}{ ...options, signal: current.signal }).then((result) => {
+      data.value = result;
+    }, (err) => {
+      if (!current.signal.aborted) {
+        error.value = err;
+      }
+    }).then(() => {
+      if (controller === current) {
+        loading.value = false;
+      }
+    });
+  };
+
+  onUnmounted(() => {
+    if (controller) {
+      controller.abort();
+    }
+  });
+
+  return {
+    data: readonly(data) as Readonly<Ref<{{ $returnType }} | null>>,
+    loading: readonly(loading),
+    error: readonly(error) as Readonly<Ref<Error | null>>,
+    execute: execute,
+  };
+}
+    {{- end }}
+  {{- end }}
+{{- end }}
+{{- end }}
+{{- if .EmitRxjs }}
+{{- range $url, $path := .Paths }}
+  {{- range $method, $operation := $path }}
+    {{- if not $operation.XNakamaSse }}
+    {{- $name := $operation.Name | stripOperationPrefix | snakeToCamel }}
+
+/** Call {{ $name | escapeReserved }} on subscription. Unsubscribing aborts the request. */
+export function {{ $name }}Observable(api: {{ $.Prefix }}{{ $.Namespace }}{{ $.ApiSuffix }}Api{{ range operationParameters $operation }}, {{ . }}{{ end }}, options: any = {}): Observable<{{ resultType $operation }}> {
+  return new Observable<{{ resultType $operation }}>((subscriber) => {
+    const controller = new AbortController();
+    const subscription = from(api.{{ $name | escapeReserved }}({{ range operationArguments $operation }}{{ . }}, {{ end }}{ ...options, signal: controller.signal })).subscribe(subscriber);
+    return () => {
+      subscription.unsubscribe();
+      controller.abort();
+    };
+  });
+}
+    {{- end }}
+  {{- end }}
+{{- end }}
+{{- end }}
+{{- if .EmitAngular }}
+{{- $api := print .Prefix .Namespace .ApiSuffix "Api" }}
+{{- $service := print .Prefix .Namespace .ApiSuffix "Service" }}
+{{- $key := "serverKey" }}
+{{- if eq .Namespace "Satori" }}{{ $key = "apiKey" }}{{ end }}
+
+/** The arguments {{ $service }} constructs its {{ $api }} with. */
+export interface {{ $service }}Config {
+  {{ $key }}: string;
+  basePath: string;
+  timeoutMs: number;
+  configuration?: ConfigurationParameters;
+}
+
+/** The injection token which provides the {{ $service }}Config. */
+export const {{ .Namespace | uppercase }}{{ .ApiSuffix | uppercase }}_SERVICE_CONFIG = new InjectionToken<{{ $service }}Config>("{{ $service }}Config");
+
+/** An injectable {{ $api }} whose methods return Observables. Unsubscribing aborts the request. */
+@Injectable({ providedIn: 'root' })
+export class {{ $service }} {
+  readonly api: {{ $api }};
+
+  constructor(@Inject({{ .Namespace | uppercase }}{{ .ApiSuffix | uppercase }}_SERVICE_CONFIG) config: {{ $service }}Config) {
+    this.api = new {{ $api }}(config.{{ $key }}, config.basePath, config.timeoutMs, config.configuration);
+  }
+{{- range $url, $path := .Paths }}
+  {{- range $method, $operation := $path }}
+    {{- if not $operation.XNakamaSse }}
+    {{- $name := $operation.Name | stripOperationPrefix | snakeToCamel | escapeReserved }}
+
+  /** {{ $operation.Summary }} */
+  {{ $name }}({{ range operationParameters $operation }}{{ . }}, {{ end }}options: any = {}): Observable<{{ resultType $operation }}> {
+    return this.observe((signal) => this.api.{{ $name }}({{ range operationArguments $operation }}{{ . }}, {{ end }}{ ...options, signal: signal }));
+  }
+    {{- end }}
+  {{- end }}
+{{- end }}
+
+  private observe<T>(send: (signal: AbortSignal) => Promise<T>): Observable<T> {
+    return new Observable<T>((subscriber) => {
+      const controller = new AbortController();
+      const subscription = from(send(controller.signal)).subscribe(subscriber);
+      return () => {
+        subscription.unsubscribe();
+        controller.abort();
+      };
+    });
+  }
+}
+{{- end }}
+{{- if .EmitMock }}
+
+type MockMethod<F extends (...args: any[]) => any> = F & { mock: { calls: Parameters<F>[] } };
+
+function mockMethod<F extends (...args: any[]) => any>(value: any): MockMethod<F> {
+  const calls: Parameters<F>[] = [];
+  const method = (...args: Parameters<F>) => {
+    calls.push(args);
+    return Promise.resolve(value);
+  };
+  return Object.assign(method, { mock: { calls } }) as unknown as MockMethod<F>;
+}
+
+/**
+ * Create a stand-in for {{ .Prefix }}{{ .Namespace }}{{ .ApiSuffix }}Api for unit tests. Each method records its calls in
+ * mock.calls, like jest.fn(), and resolves to its entry in defaults or to {}.
+ */
+export function createMock{{ .Prefix }}{{ .Namespace }}{{ .ApiSuffix }}Api(defaults: { [K in keyof {{ .Prefix }}{{ .Namespace }}{{ .ApiSuffix }}Api]?: any } = {}) {
+  return {
+{{- range $url, $path := .Paths }}
+  {{- range $method, $operation := $path }}
+    {{- if not $operation.XNakamaSse }}
+    {{- $name := $operation.Name | stripOperationPrefix | snakeToCamel | escapeReserved }}
+    {{ $name }}: mockMethod<{{ $.Prefix }}{{ $.Namespace }}{{ $.ApiSuffix }}Api["{{ $name }}"]>("{{ $name }}" in defaults ? defaults.{{ $name }} : {}),
+    {{- end }}
+  {{- end }}
+{{- end }}
+  };
+}
+{{- end }}
+{{- if and .EmitOfflineQueue (offlineQueued .Paths) }}
+{{- $api := print .Prefix .Namespace .ApiSuffix "Api" }}
+
+/**
+ * Send the operations marked x-nakama-offline-safe through {{ $api }} while the device is online, and store them in
+ * localStorage while it is offline. The stored requests are replayed in order when the device reconnects. A request
+ * which fails is retried after the backoff of the API client's retries until it failed maxAttempts times, and then
+ * dropped. Session tokens are not stored: each request asks getBearerToken for a current one when it is sent.
+ */
+export class {{ .Prefix }}{{ .Namespace }}{{ .ApiSuffix }}OfflineQueue {
+  // The replay in progress, shared by concurrent calls to flush.
+  private flushing: Promise<void> | null = null;
+
+  constructor(readonly api: {{ $api }}, readonly getBearerToken: () => string | Promise<string>, readonly maxAttempts: number = 3, readonly storageKey: string = "{{ $api }}-offline-queue") {
+    if (typeof window !== "undefined") {
+      window.addEventListener("online", () => this.flush());
+    }
+    // requests stored in an earlier session are sent as soon as possible.
+    this.flush();
+  }
+{{- range offlineQueued .Paths }}
+    {{- $name := .Name | stripOperationPrefix | snakeToCamel | escapeReserved }}
+    {{- $credentials := len (credentials .Operation) }}
+
+  /** {{ .Summary }} Resolves to undefined when the request is queued. */
+  {{ $name }}({{ range $i, $parameter := slice (operationParameters .Operation) $credentials }}{{ if $i }}, {{ end }}{{ $parameter }}{{ end }}): Promise<{{ resultType .Operation }} | undefined> {
+    return this.send({method: "{{ $name }}", args: [{{ range $i, $argument := slice (operationArguments .Operation) $credentials }}{{ if $i }}, {{ end }}{{ $argument }}{{ end }}], bearer: {{ if $credentials }}true{{ else }}false{{ end }}, attempts: 0});
+  }
+{{- end }}
+
+  /** The requests waiting to be sent, oldest first. */
+  pending(): {{ .Prefix }}{{ .Namespace }}QueuedRequest[] {
+    if (typeof localStorage === "undefined") {
+      return [];
+    }
+    return JSON.parse(localStorage.getItem(this.storageKey) || "[]");
+  }
+
+  /** Replay the stored requests in order while the device is online. */
+  flush(): Promise<void> {
+    if (!this.flushing) {
+      this.flushing = this.replay().then(() => {
+        this.flushing = null;
+      });
+    }
+    return this.flushing;
+  }
+
+  private online(): boolean {
+    return typeof navigator === "undefined" || navigator.onLine;
+  }
+
+  private store(queue: {{ .Prefix }}{{ .Namespace }}QueuedRequest[]) {
+    localStorage.setItem(this.storageKey, JSON.stringify(queue));
+  }
+
+  // call the API method of a request, with a session token fetched now rather than the one current when it was queued.
+  private call(request: {{ .Prefix }}{{ .Namespace }}QueuedRequest): Promise<any> {
+    return Promise.resolve(request.bearer ? this.getBearerToken() : "").then((bearerToken) => {
+      const args = request.bearer ? [bearerToken, ...request.args] : request.args;
+      return (this.api as any)[request.method](...args);
+    });
+  }
+
+  private send(request: {{ .Prefix }}{{ .Namespace }}QueuedRequest): Promise<any> {
+    if (this.online() && this.pending().length == 0) {
+      return this.call(request);
+    }
+    // while older requests wait, new ones are stored behind them to keep the order.
+    this.store([...this.pending(), request]);
+    this.flush();
+    return Promise.resolve(undefined);
+  }
+
+  private replay(): Promise<void> {
+    const request = this.pending()[0];
+    if (!request || !this.online()) {
+      return Promise.resolve();
+    }
+    // requests queued while this one is sent are kept, so the queue is read again once it settles.
+    return this.call(request).then(() => {
+      this.store(this.pending().slice(1));
+    }, () => {
+      request.attempts++;
+      const rest = this.pending().slice(1);
+      this.store(request.attempts < this.maxAttempts ? [request, ...rest] : rest);
+      // back off like the retries of the API client, so a failing request is not sent again in a tight loop.
+      const configuration = this.api.configuration;
+      const maxDelay = Math.min(configuration.retryMaxDelayMs ?? defaultConfiguration.retryMaxDelayMs, (configuration.retryBaseDelayMs ?? defaultConfiguration.retryBaseDelayMs) * Math.pow(2, request.attempts - 1));
+      return new Promise((resolve) => setTimeout(resolve, Math.random() * maxDelay));
+    }).then(() => this.replay());
+  }
+}
+{{- end }}
+{{- if .EmitHealthCheck }}
+{{- with healthOperation .Paths }}
+{{- $api := print $.Prefix $.Namespace $.ApiSuffix "Api" }}
+
+/**
+ * Call {{ .Name | stripOperationPrefix | snakeToCamel | escapeReserved }} every intervalMs to keep the connection to the server alive, and dispatch an event
+ * named after the new state when it changes: "healthy", "degraded" when the server answers slower than degradedMs or
+ * failed fewer than downAfter times in a row, and "down" after that.
+ */
+export class {{ $.Prefix }}{{ $.Namespace }}HealthChecker extends EventTarget {
+  state: "unknown" | "healthy" | "degraded" | "down" = "unknown";
+  private failures = 0;
+  // incremented by start and stop, so the checks of an earlier run do not schedule more.
+  private run = 0;
+  private timer: ReturnType<typeof setTimeout> | null = null;
+
+  constructor(readonly api: {{ $api }}, readonly intervalMs: number = 30000, readonly degradedMs: number = 2000, readonly downAfter: number = 3) {
+    super();
+  }
+
+  /** Start checking, immediately and then every intervalMs. */
+  start() {
+    this.stop();
+    this.check(this.run);
+  }
+
+  /** Stop checking until start is called again. */
+  stop() {
+    this.run++;
+    if (this.timer !== null) {
+      clearTimeout(this.timer);
+      this.timer = null;
+    }
+  }
+
+  private check(run: number) {
+    const started = Date.now();
+    this.api.{{ .Name | stripOperationPrefix | snakeToCamel | escapeReserved }}({{ range $i, $name := credentials .Operation }}{{ if $i }}, {{ end }}""{{ end }}).then(() => {
+      this.failures = 0;
+      this.update(Date.now() - started > this.degradedMs ? "degraded" : "healthy");
+    }, () => {
+      this.failures++;
+      this.update(this.failures >= this.downAfter ? "down" : "degraded");
+    }).then(() => {
+      if (run == this.run) {
+        this.timer = setTimeout(() => this.check(run), this.intervalMs);
+      }
+    });
+  }
+
+  private update(state: "healthy" | "degraded" | "down") {
+    if (state != this.state) {
+      this.state = state;
+      this.dispatchEvent(new Event(state));
+    }
+  }
+}
+{{- end }}
+{{- end }}
+{{- end }}
+{{- if .TsNamespace }}
+
+}
+{{- end }}
+`
+
+// cloudRunTemplate renders a Cloud Run Job entrypoint which calls a list of
+// operations, named in the NAKAMA_OPERATIONS environment variable, in sequence.
+// Only operations without required parameters can be scheduled this way.
+const cloudRunTemplate string = `
+{{- if not .NoBanner -}}
+// tslint:disable
+/* Code generated by openapi-gen/main.go. DO NOT EDIT. */
+
+{{ end -}}
+import { {{ .Prefix }}{{ .Namespace }}Api } from '{{ .ClientModule }}';
+
+declare const process: any;
+
+const env = process.env;
+const operations = (env.NAKAMA_OPERATIONS || "").split(",").map((id: string) => id.trim()).filter((id: string) => id);
+const bearerToken = env.NAKAMA_BEARER_TOKEN || "";
+const basicAuthUsername = env.NAKAMA_BASIC_AUTH_USERNAME || "";
+const basicAuthPassword = env.NAKAMA_BASIC_AUTH_PASSWORD || "";
+
+const api = new {{ .Prefix }}{{ .Namespace }}Api(env.NAKAMA_SERVER_KEY || "", env.NAKAMA_BASE_PATH || "http://127.0.0.1:7350", Number(env.NAKAMA_TIMEOUT_MS || 7000));
+
+const invokers: Record<string, () => Promise<any>> = {
+{{- range $url, $path := .Paths}}
+  {{- range $method, $operation := $path}}
+    {{- $required := false }}
+    {{- range $parameter := $operation.Parameters }}
+      {{- if $parameter.Required }}{{ $required = true }}{{ end }}
+    {{- end }}
+    {{- if not $required }}
+  "{{ $operation.OperationId }}": () => api.{{ $operation.Name | stripOperationPrefix | snakeToCamel | escapeReserved }}(
+    {{- if $operation.Security }}
+      {{- range $idx, $security := $operation.Security }}
+        {{- range $key, $value := $security }}
+          {{- if or (eq $key "BasicAuth") (eq $key "HttpKeyAuth") -}}
+    basicAuthUsername, basicAuthPassword
+          {{- else if eq $key "BearerJwt" -}}
+    bearerToken
+          {{- end }}
+        {{- end }}
+      {{- end }}
+    {{- else -}}
+    bearerToken
+    {{- end -}}
+  ),
+    {{- end }}
+  {{- end }}
+{{- end }}
+};
+
+async function main() {
+  for (const id of operations) {
+    const invoke = invokers[id];
+    if (!invoke) {
+      throw new Error("Unknown or unschedulable operation: " + id);
+    }
+
+    console.log("Calling " + id);
+    await invoke();
+  }
+}
+
+main().then(() => {
+  process.exit(0);
+}).catch((err) => {
+  console.error(err);
+  process.exit(1);
+});
+`
+
+// serviceWorkerTemplate renders a service worker which caches GET responses
+// from the API so they can be served while the device is offline. Each route
+// uses the strategy named by its x-nakama-cache-strategy extension and falls
+// back to "network-first".
+const serviceWorkerTemplate string = `
+{{- if not .NoBanner -}}
+// tslint:disable
+/* Code generated by openapi-gen/main.go. DO NOT EDIT. */
+
+{{ end -}}
+declare const self: any;
+
+const CACHE_NAME = "{{ .Namespace | lowercase }}-api-v1";
+
+type CacheStrategy = "network-first" | "cache-first" | "stale-while-revalidate";
+
+const routes: Array<{ pattern: RegExp, strategy: CacheStrategy }> = [
+{{- range $url, $path := .Paths}}
+  {{- range $method, $operation := $path}}
+    {{- if and (eq $method "get") (ne $operation.XNakamaCacheStrategy "network-only") }}
+  { pattern: /{{ $url | pathPattern }}$/, strategy: "{{ if $operation.XNakamaCacheStrategy }}{{ $operation.XNakamaCacheStrategy }}{{ else }}network-first{{ end }}" },
+    {{- end }}
+  {{- end }}
+{{- end }}
+];
+
+function networkFirst(request: Request): Promise<Response> {
+  return fetch(request).then((response) => {
+    if (response.ok) {
+      const copy = response.clone();
+      caches.open(CACHE_NAME).then((cache) => cache.put(request, copy));
+    }
+    return response;
+  }).catch((err) => {
+    return caches.match(request).then((cached) => {
+      if (cached) {
+        return cached;
+      }
+      throw err;
+    });
+  });
+}
+
+function cacheFirst(request: Request): Promise<Response> {
+  return caches.match(request).then((cached) => cached || networkFirst(request));
+}
+
+function staleWhileRevalidate(request: Request): Promise<Response> {
+  return caches.match(request).then((cached) => {
+    const network = networkFirst(request);
+    if (cached) {
+      // the cached response is served either way, so a failed revalidation only keeps it for longer.
+      network.catch(() => undefined);
+      return cached;
+    }
+    return network;
+  });
+}
+
+self.addEventListener("fetch", (event: any) => {
+  const request: Request = event.request;
+  if (request.method !== "GET") {
+    return;
+  }
+
+  const path = new URL(request.url).pathname;
+  const route = routes.find((r) => r.pattern.test(path));
+  if (!route) {
+    return;
+  }
+
+  switch (route.strategy) {
+    case "cache-first":
+      event.respondWith(cacheFirst(request));
+      break;
+    case "stale-while-revalidate":
+      event.respondWith(staleWhileRevalidate(request));
+      break;
+    default:
+      event.respondWith(networkFirst(request));
+  }
+});
+`
+
+// indexTemplate renders an index.ts which re-exports every symbol of the
+// generated client by name, interfaces as types and everything else as values.
+const indexTemplate string = `
+{{- if not .NoBanner -}}
+// tslint:disable
+/* Code generated by openapi-gen/main.go. DO NOT EDIT. */
+{{- end }}
+{{- $sse := false }}
+{{- $blob := false }}
+{{- $progress := false }}
+{{- range $url, $path := .Paths }}
+  {{- range $method, $operation := $path }}
+    {{- if $operation.XNakamaSse }}{{ $sse = true }}{{ end }}
+    {{- if eq (responseType $operation.Produces) "blob" }}{{ $blob = true }}{{ $progress = true }}{{ end }}
+    {{- if uploadsFile $operation }}{{ $progress = true }}{{ end }}
+  {{- end }}
+{{- end }}
+{{- if not .NoBanner }}
+
+{{ end -}}
+export {
+  {{ .Prefix }}{{ .Namespace }}{{ .ApiSuffix }}Api,
+  SDK_VERSION,
+{{- if hasBytes }}
+  base64ToUint8Array,
+{{- end }}
+{{- if jsonConversions }}
+  convertJson,
+{{- end }}
+{{- if or bigints hasBytes }}
+  jsonReplacer,
+{{- end }}
+{{- if $sse }}
+  EventStream,
+{{- end }}
+{{- if .EmitMock }}
+  createMock{{ .Prefix }}{{ .Namespace }}{{ .ApiSuffix }}Api,
+{{- end }}
+{{- if and .EmitOfflineQueue (offlineQueued .Paths) }}
+  {{ .Prefix }}{{ .Namespace }}{{ .ApiSuffix }}OfflineQueue,
+{{- end }}
+{{- if .EmitHealthCheck }}
+  {{ .Prefix }}{{ .Namespace }}HealthChecker,
+{{- end }}
+{{- if .EmitTypeGuards }}
+{{- range $classname, $definition := .Definitions }}
+  {{- if not (isRefToEnum $classname) }}
+  is{{ $classname | cleanRef }},
+  {{- end }}
+{{- end }}
+{{- end }}
+{{- if .ValidateResponses }}
+  {{ .Namespace }}ValidationError,
+  validateResponse,
+{{- end }}
+{{- if .EmitAngular }}
+  {{ .Prefix }}{{ .Namespace }}{{ .ApiSuffix }}Service,
+  {{ .Namespace | uppercase }}{{ .ApiSuffix | uppercase }}_SERVICE_CONFIG,
+{{- end }}
+{{- if .EmitRxjs }}
+{{- range $url, $path := .Paths }}
+  {{- range $method, $operation := $path }}
+    {{- if not $operation.XNakamaSse }}
+  {{ $operation.Name | stripOperationPrefix | snakeToCamel }}Observable,
+    {{- end }}
+  {{- end }}
+{{- end }}
+{{- end }}
+{{- if or .EmitReactHooks .EmitVueComposables }}
+{{- range $url, $path := .Paths }}
+  {{- range $method, $operation := $path }}
+    {{- if not $operation.XNakamaSse }}
+  use{{ $operation.Name | stripOperationPrefix | snakeToCamel | camelToPascal }},
+    {{- end }}
+  {{- end }}
+{{- end }}
+{{- end }}
+{{- range $url, $path := .Paths }}
+  {{- range $method, $operation := $path }}
+    {{- if and $.EmitResponseTypes $operation.Responses.Ok.Headers }}
+  extractHeaders{{ $operation.Name | stripOperationPrefix | snakeToCamel | camelToPascal }},
+    {{- end }}
+  {{- end }}
+{{- end }}
+{{- range $classname, $definition := .Definitions }}
+  {{- if isRefToEnum $classname }}
+  {{ $classname | cleanRef }},
+  {{- end }}
+{{- end }}
+} from '{{ .ClientModule }}';
+
+export type {
+  ConfigurationParameters,
+{{- if and .EmitOfflineQueue (offlineQueued .Paths) }}
+  {{ .Prefix }}{{ .Namespace }}QueuedRequest,
+{{- end }}
+{{- if .EmitAngular }}
+  {{ .Prefix }}{{ .Namespace }}{{ .ApiSuffix }}ServiceConfig,
+{{- end }}
+{{- if .EmitOtel }}
+  Span,
+{{- end }}
+{{- if $progress }}
+  TransferProgressOptions,
+{{- end }}
+{{- if $blob }}
+  BlobDownloadOptions,
+{{- end }}
+{{- range $url, $path := .Paths }}
+  {{- range $method, $operation := $path }}
+    {{- if requestParameters $operation }}
+  {{ $.Prefix }}{{ $operation.Name | stripOperationPrefix | snakeToCamel | camelToPascal }}Request,
+    {{- end }}
+    {{- if and $.EmitResponseTypes (not $operation.XNakamaSse) }}
+  {{ $.Prefix }}{{ $operation.Name | stripOperationPrefix | snakeToCamel | camelToPascal }}Response,
+    {{- end }}
+    {{- if and $.EmitResponseTypes $operation.Responses.Ok.Headers }}
+  {{ $.Prefix }}{{ $operation.Name | stripOperationPrefix | snakeToCamel | camelToPascal }}Headers,
+    {{- end }}
+  {{- end }}
+{{- end }}
+{{- range $enum := integerEnums .Definitions }}
+  {{ $enum.Name }},
+{{- end }}
+{{- range $classname, $definition := .Definitions }}
+  {{- if not (isRefToEnum $classname) }}
+  {{ $classname | cleanRef }},
+  {{- end }}
+{{- end }}
+} from '{{ .ClientModule }}';
+`
+
+// postmanTemplate renders a Postman Collection v2.1 with one request per
+// operation. Path parameters and credentials are collection variables.
+const postmanTemplate string = `{
+  "info": {
+    "name": {{ json (print .Prefix .Namespace " API") }},
+    "description": "Generated by openapi-gen/main.go.",
+    "schema": "https://schema.getpostman.com/json/collection/v2.1.0/collection.json"
+  },
+  "item": [
+{{- range $i, $operation := operations .Paths }}
+    {{- if $i }},{{ end }}
+    {
+      "name": {{ json ($operation.Name | stripOperationPrefix) }},
+      "request": {
+        "method": "{{ $operation.Method | uppercase }}",
+        "description": {{ json $operation.Summary }},
+        "header": [
+          {
+            "key": "Authorization",
+            "value": {{ json (authorizationPlaceholder $operation.Operation) }}
+          }
+          {{- if requestExample $operation.Operation }},
+          {
+            "key": "Content-Type",
+            "value": "application/json"
+          }
+          {{- end }}
+        ],
+        "url": {
+          "raw": {{ json (print "{{baseUrl}}" (variableUrl $operation.Url)) }},
+          "host": ["{{ "{{baseUrl}}" }}"],
+          "path": {{ json (variablePath $operation.Url) }},
+          "query": [
+          {{- range $j, $parameter := queryParameters $operation.Operation }}
+            {{- if $j }},{{ end }}
+            {
+              "key": {{ json $parameter.Name }},
+              "value": "",
+              "disabled": {{ not $parameter.Required }}
+            }
+          {{- end }}
+          ]
+        }
+        {{- with requestExample $operation.Operation }},
+        "body": {
+          "mode": "raw",
+          "raw": {{ json . }},
+          "options": {
+            "raw": {
+              "language": "json"
+            }
+          }
+        }
+        {{- else }}{{ with formParameters $operation.Operation }},
+        "body": {
+          "mode": "formdata",
+          "formdata": [
+          {{- range $j, $parameter := . }}
+            {{- if $j }},{{ end }}
+            {
+              "key": {{ json $parameter.Name }},
+              "type": "{{ if eq $parameter.Type "file" }}file{{ else }}text{{ end }}"
+            }
+          {{- end }}
+          ]
+        }
+        {{- end }}{{ end }}
+      }
+    }
+{{- end }}
+  ],
+  "variable": [
+    {
+      "key": "baseUrl",
+      "value": "http://127.0.0.1:7350"
+    },
+    {
+      "key": "bearerToken",
+      "value": ""
+    },
+    {
+      "key": "basicAuth",
+      "value": ""
+    }
+{{- range $name := pathVariables .Paths }},
+    {
+      "key": {{ json $name }},
+      "value": ""
+    }
+{{- end }}
+  ]
+}
+`
+
+// httpTemplate renders a JetBrains HTTP Client file with one request per
+// operation, grouped by tag. Required parameters are file variables.
+const httpTemplate string = `# Code generated by openapi-gen/main.go. DO NOT EDIT.
+
+@baseUrl = http://127.0.0.1:7350
+@bearerToken =
+@basicAuth =
+{{- range requiredParameters .Paths }}
+@{{ .Name }} = {{ placeholder . }}
+{{- end }}
+{{- range tagGroups .Paths }}
+
+### Tag: {{ .Tag }}
+{{- range .Operations }}
+
+### {{ .Name | stripOperationPrefix }}
+{{- with .Summary }}
+# {{ replace . "\n" "\n# " }}
+{{- end }}
+{{ .Method | uppercase }} {{ "{{baseUrl}}" }}{{ variableUrl .Url }}{{ variableQuery .Operation }}
+Authorization: {{ authorizationPlaceholder .Operation }}
+{{- with requestExample .Operation }}
+Content-Type: application/json
+
+{{ . }}
+{{- end }}
+{{- end }}
+{{- end }}
+`
+
+// brunoTemplate renders the Bruno request file of a single operation.
+const brunoTemplate string = `meta {
+  name: {{ .Name | stripOperationPrefix }}
+  type: http
+  seq: {{ .Seq }}
+}
+
+{{ .Method }} {
+  url: {{ "{{baseUrl}}" }}{{ variableUrl .Url }}{{ variableQuery .Operation }}
+  body: {{ if requestExample .Operation }}json{{ else if formParameters .Operation }}multipartForm{{ else }}none{{ end }}
+  auth: none
+}
+{{- with queryParameters .Operation }}
+
+params:query {
+{{- range . }}
+  {{ if not .Required }}~{{ end }}{{ .Name }}:{{ if .Required }} {{ "{{" }}{{ .Name }}{{ "}}" }}{{ end }}
+{{- end }}
+}
+{{- end }}
+
+headers {
+  Authorization: {{ authorizationPlaceholder .Operation }}
+{{- if requestExample .Operation }}
+  Content-Type: application/json
+{{- end }}
+}
+{{- with requestExample .Operation }}
+
+body:json {
+  {{ replace . "\n" (print "\n" $.Indent) }}
+}
+{{- else }}{{ with formParameters .Operation }}
+
+body:multipart-form {
+{{- range . }}
+  {{ .Name }}:
+{{- end }}
+}
+{{- end }}{{ end }}
+{{- with operationVariables .Operation }}
+
+vars:pre-request {
+{{- range . }}
+  {{ .Name }}: {{ placeholder . }}
+{{- end }}
+}
+{{- end }}
+{{- with .Summary }}
+
+docs {
+  {{ replace . "\n" (print "\n" $.Indent) }}
+}
+{{- end }}
+`
+
+// docsTemplate renders a Markdown reference of the operations, grouped by tag,
+// and of the definitions.
+const docsTemplate string = `# {{ .Prefix }}{{ .Namespace }} API
+
+<!-- Code generated by openapi-gen/main.go. DO NOT EDIT. -->
+{{- range tagGroups .Paths }}
+
+## {{ .Tag }}
+
+| Method | Path | Description | Parameters | Response |
+| --- | --- | --- | --- | --- |
+{{- range .Operations }}
+| {{ .Method | uppercase | code }} | {{ code .Url }} | {{ markdownCell .Summary }} | {{ range $i, $parameter := .Parameters }}{{ if $i }}, {{ end }}{{ code $parameter.Name }}{{ end }} | {{ markdownReturnType .Operation }} |
+{{- end }}
+{{- range .Operations }}
+
+### {{ .Name | stripOperationPrefix }}
+
+{{ code (print (uppercase .Method) " " .Url) }}
+{{- with .Summary }}
+
+{{ . }}
+{{- end }}
+{{- with .Parameters }}
+
+| Name | Type | Required | Description |
+| --- | --- | --- | --- |
+{{- range . }}
+| {{ code .Name }} | {{ parameterType . | code }} | {{ if .Required }}yes{{ else }}no{{ end }} | {{ markdownCell .Description }} |
+{{- end }}
+{{- end }}
+{{- end }}
+{{- end }}
+
+## Definitions
+{{- range $name, $definition := .Definitions }}
+
+### {{ cleanRef $name }}
+{{- with $definition.Description }}
+
+{{ . }}
+{{- end }}
+{{- if $definition.Enum }}
+
+Values: {{ range $i, $value := $definition.Enum }}{{ if $i }}, {{ end }}{{ code $value }}{{ end }}
+{{- else if $definition.Properties }}
+
+| Field | Type | Description |
+| --- | --- | --- |
+{{- range $key, $property := $definition.Properties }}
+| {{ camelToSnake $key | code }} | {{ markdownType $property }} | {{ markdownCell $property.Description }} |
+{{- end }}
+{{- end }}
+{{- end }}
+`
+
+// Property is a single field of a definition.
+type Property struct {
+	Type  string
+	Ref   string   `json:"$ref"` // used with object
+	Items struct { // used with type "array"
+		Type string
+		Ref  string `json:"$ref"`
+	}
+	AdditionalProperties struct {
+		Type string // used with type "map"
+	}
+	Format      string // used with types "integer", "string" and "boolean"
+	Description string
+	Deprecated  bool
+	ReadOnly    bool              // set by the server and never sent in requests
+	WriteOnly   bool              // sent in requests and never returned by the server
+	XNullable   bool              `json:"x-nullable"`
+	XTsType     string            `json:"x-ts-type"` // emitted verbatim instead of the derived type
+	Default     json.RawMessage   // the value used when the field is absent
+	Enum        []json.RawMessage // used with inline enums
+	XEnumNames  []string          `json:"x-enum-names"` // names the values of an integer enum
+	// XEnumVarnames names the values of an integer enum in code, taking
+	// precedence over XEnumNames.
+	XEnumVarnames []string `json:"x-enum-varnames"`
+	Constraints
+}
+
+// Constraints are the validation keywords of a property or parameter. They
+// are only documented, not enforced.
+type Constraints struct {
+	// used with types "integer" and "number"
+	Minimum          *float64
+	Maximum          *float64
+	ExclusiveMinimum bool
+	ExclusiveMaximum bool
+	// used with type "string"
+	Pattern   string
+	MinLength *int
+	MaxLength *int
+}
+
+type Definition struct {
+	Properties               map[string]Property
+	Required                 []string // names of the properties which are always present
+	AdditionalPropertiesBool *bool    `json:"-"` // nil when additionalProperties is absent
+	Enum                     []string
+	Description              string
+	// used only by enums
+	Title string
+}
+
+// UnmarshalJSON decodes a definition. Its additionalProperties is either a
+// boolean or a schema, and any schema, including {}, is treated like true.
+func (d *Definition) UnmarshalJSON(data []byte) error {
+	type definition Definition
+	var raw struct {
+		definition
+		AdditionalProperties json.RawMessage
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*d = Definition(raw.definition)
+	if len(raw.AdditionalProperties) > 0 && string(raw.AdditionalProperties) != "null" {
+		allowed := string(raw.AdditionalProperties) != "false"
+		d.AdditionalPropertiesBool = &allowed
+	}
+	return nil
+}
+
+// AllowsAdditionalProperties reports whether a definition accepts properties
+// which it does not list, such as the arbitrary data of a storage object.
+func (d Definition) AllowsAdditionalProperties() bool {
+	return d.AdditionalPropertiesBool != nil && *d.AdditionalPropertiesBool
+}
+
+// HasDefaults reports whether any property of a definition has a default.
+func (d Definition) HasDefaults() bool {
+	for _, property := range d.Properties {
+		if len(property.Default) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// HeaderDefinition is a header sent with a successful response.
+type HeaderDefinition struct {
+	Type        string
+	Format      string
+	Description string
+}
+
+// Parameter is a single parameter of an API operation.
+type Parameter struct {
+	Name        string
+	Description string
+	In          string
+	Required    bool
+	Type        string   // used with primitives
+	Format      string   // used with primitives
+	Items       struct { // used with type "array"
+		Type string
+	}
+	AdditionalProperties struct { // used with type "object"
+		Type string
+	}
+	Schema struct { // used with http body
+		Type string
+		Ref  string `json:"$ref"`
+	}
+	File      bool `json:"-"` // set for "formData" parameters of type "file"
+	Multipart bool `json:"-"` // set for the body of "multipart/form-data" operations
+	Constraints
+}
+
+// Operation is a single HTTP method of an API path.
+type Operation struct {
+	Summary     string
+	OperationId string
+	Tags        []string
+	Deprecated  bool
+	Consumes    []string // the media types of the request, else those of the specification
+	Produces    []string // the media types of the response, else those of the specification
+	Responses   struct {
+		Ok struct {
+			Description string
+			Schema      struct {
+				Type string
+				Ref  string `json:"$ref"`
+			}
+			Headers map[string]HeaderDefinition
+		} `json:"200"`
+	}
+	Parameters            []Parameter
+	Security              []map[string][]struct{}
+	XNakamaIdempotencyKey bool   `json:"x-nakama-idempotency-key"`
+	XNakamaCacheStrategy  string `json:"x-nakama-cache-strategy"`
+	XNakamaSse            bool   `json:"x-nakama-sse"`
+	XNakamaOfflineSafe    bool   `json:"x-nakama-offline-safe"` // queued by --emit-offline-queue while offline
+	XNakamaHealth         bool   `json:"x-nakama-health"`       // polled by --emit-health-check
+	XDeprecatedReason     string `json:"x-deprecated-reason"`   // also marks the operation deprecated
+	XOperationId          string `json:"x-operation-id"`        // names the generated method instead of OperationId
+	// OpenAPI 3 replaces the "in: body" parameter with a request body.
+	RequestBody struct {
+		Required bool
+		Content  map[string]MediaType // by content type
+	}
+}
+
+// MediaType is the body of an OpenAPI 3 request in one content type.
+type MediaType struct {
+	Schema struct {
+		Type string
+		Ref  string `json:"$ref"`
+	}
+}
+
+// Name returns the identifier the generated code is named after: the
+// x-operation-id extension if present, else the operationId.
+func (o Operation) Name() string {
+	if o.XOperationId != "" {
+		return o.XOperationId
+	}
+	return o.OperationId
+}
+
+// bodyParameter returns the "in: body" parameter equivalent to the OpenAPI 3
+// requestBody of an operation, preferring its application/json content.
+func (o Operation) bodyParameter() (Parameter, bool) {
+	if len(o.RequestBody.Content) == 0 {
+		return Parameter{}, false
+	}
+
+	contentType := "application/json"
+	if _, ok := o.RequestBody.Content[contentType]; !ok {
+		contentTypes := make([]string, 0, len(o.RequestBody.Content))
+		for key := range o.RequestBody.Content {
+			contentTypes = append(contentTypes, key)
+		}
+		sort.Strings(contentTypes)
+		contentType = contentTypes[0]
+	}
+
+	parameter := Parameter{Name: "body", In: "body", Required: o.RequestBody.Required, Multipart: contentType == "multipart/form-data"}
+	parameter.Schema = o.RequestBody.Content[contentType].Schema
+	return parameter, true
+}
+
+// Schema is a decoded Swagger specification together with the options used
+// to render it.
+type Schema struct {
+	Namespace          string
+	Prefix             string // prepended to the API class and definition names
+	TsNamespace        string // wraps the output in an exported namespace
+	ClientModule       string
+	Bigint             bool // emit bigint for int64 integers
+	DateReviver        bool
+	DefinitionsOnly    bool   // render only the type definitions
+	ApiOnly            bool   // render only the API class
+	ApiSuffix          string // appended to the API class name
+	Strict             bool   // emit required properties as non-optional
+	EmitZod            bool   // emit a Zod schema for each definition
+	EmitIoTs           bool   // emit an io-ts codec for each definition
+	EmitMock           bool   // emit a mock API factory for unit tests
+	EmitDefaults       bool   // emit the default values of definitions
+	EmitOtel           bool   // emit OpenTelemetry tracing of requests
+	EmitReactHooks     bool   // emit a React hook for each operation
+	EmitVueComposables bool   // emit a Vue 3 composable for each operation
+	EmitRxjs           bool   // emit an RxJS Observable wrapper for each operation
+	EmitAngular        bool   // emit an injectable Angular service
+	ValidateResponses  bool   // parse responses with their Zod schemas
+	EmitTypeGuards     bool   // emit a type guard function for each definition
+	NoConstEnum        bool   // emit integer enums as plain instead of const enums
+	Quiet              bool   // do not print warnings
+	NoBanner           bool   // leave out the "DO NOT EDIT" header
+	ImportType         bool   // import interfaces with "import type"
+	EmitRequestTypes   bool   // bundle the non-path parameters of each operation in an interface
+	EmitResponseTypes  bool   // resolve with the body, status and headers of each response
+	GroupByTag         bool   // also group the methods in an object per tag
+	EmitDedup          bool   // share the promise of identical concurrent GET requests
+	EmitOfflineQueue   bool   // emit a queue storing offline-safe operations while offline
+	EmitHealthCheck    bool   // emit a class polling the health check operation
+	InlineUtils        bool   // emit buildFetchOptions instead of importing it from ./utils
+	Indent             string // one level of indentation in the generated code
+	Info               struct {
+		Version string
+		Title   string
+	}
+	Consumes []string // inherited by operations which declare none, in parseSchema
+	Produces []string
+	// text/template ranges over maps in sorted key order, so the generated
+	// code does not depend on Go's randomized map iteration.
+	Paths       map[string]map[string]Operation
+	Definitions map[string]Definition
+	Defs        map[string]Definition `json:"$defs"` // merged into Definitions by parseSchema
+	Components  struct {              // OpenAPI 3, merged into Definitions by parseSchema
+		Schemas map[string]Definition
+	}
+}
+
+// UnmarshalJSON decodes a specification, reading its paths as path items.
+func (s *Schema) UnmarshalJSON(data []byte) error {
+	type schema Schema
+	var raw struct {
+		schema
+		Paths map[string]pathItem
+	}
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	*s = Schema(raw.schema)
+	if raw.Paths != nil {
+		s.Paths = make(map[string]map[string]Operation, len(raw.Paths))
+		for url, path := range raw.Paths {
+			s.Paths[url] = path
+		}
+	}
+	return nil
+}
+
+// pathItem is the operations of a path by method. The x-nakama-sse extension
+// of a path item marks all its operations as event streams, and its other
+// extensions are ignored.
+type pathItem map[string]Operation
+
+// UnmarshalJSON decodes a path item.
+func (p *pathItem) UnmarshalJSON(data []byte) error {
+	var raw map[string]json.RawMessage
+	if err := json.Unmarshal(data, &raw); err != nil {
+		return err
+	}
+
+	var sse bool
+	if value, ok := raw["x-nakama-sse"]; ok {
+		if err := json.Unmarshal(value, &sse); err != nil {
+			return fmt.Errorf("x-nakama-sse: %w", err)
+		}
+	}
+	*p = make(pathItem, len(raw))
+	for key, value := range raw {
+		if strings.HasPrefix(key, "x-") {
+			continue
+		}
+		var operation Operation
+		if err := json.Unmarshal(value, &operation); err != nil {
+			return err
+		}
+		operation.XNakamaSse = operation.XNakamaSse || sse
+		(*p)[key] = operation
+	}
+	return nil
+}
+
+func snakeToCamel(input string) (snakeToCamel string) {
+	isToUpper := false
+	for k, v := range input {
+		if k == 0 {
+			snakeToCamel = strings.ToLower(string(input[0]))
+		} else {
+			if isToUpper {
+				snakeToCamel += strings.ToUpper(string(v))
+				isToUpper = false
+			} else {
+				if v == '_' {
+					isToUpper = true
+				} else {
+					snakeToCamel += string(v)
+				}
+			}
+		}
+	}
+	return
+}
+
+func enumSummary(def Definition) string {
+	// quirk of swagger generation: if enum doesn't have a title
+	// then the title can be found as the first entry in the split description.
+	if def.Title != "" {
+		return def.Title
+	}
+
+	split := strings.Split(def.Description, "\n")
+
+	if len(split) <= 0 {
+		panic("No newlines in enum description found.")
+	}
+
+	return split[0]
+}
+
+func enumDescriptions(def Definition) (output []string) {
+
+	split := strings.Split(def.Description, "\n")
+
+	if len(split) <= 0 {
+		panic("No newlines in enum description found.")
+	}
+
+	if def.Title != "" {
+		return split
+	}
+
+	// quirk of swagger generation: if enum doesn't have a title
+	// then the title can be found as the first entry in the split description.
+	// so ignore for individual enum descriptions.
+	return split[2:]
+}
+
+func stripOperationPrefix(input string) string {
+	return strings.Replace(input, "Nakama_", "", 1)
+}
+
+// title upper-cases the first letter of each word. Unlike cases.Title's
+// default it leaves the remaining letters alone, so "apiAccount" becomes
+// "ApiAccount" rather than "Apiaccount".
+func title(input string) string {
+	return cases.Title(language.English, cases.NoLower).String(input)
+}
+
+func convertRefToClassName(input string) (className string) {
+	className = title(refName(input))
+	return
+}
+
+func iscamelToSnake(input string) (output bool) {
+
+	output = true
+
+	for _, v := range input {
+		vString := string(v)
+		if vString != "_" && strings.ToUpper(vString) == vString {
+			output = false
+		}
+	}
+
+	return
+}
+
+// camelToPascal converts a string from camel case to Pascal case.
+func camelToPascal(camelCase string) (pascalCase string) {
+
+	if len(camelCase) <= 0 {
+		return ""
+	}
+
+	pascalCase = strings.ToUpper(string(camelCase[0])) + camelCase[1:]
+	return
+}
+
+func camelToSnake(input string) (output string) {
+	output = ""
+
+	if iscamelToSnake(input) {
+		output = input
+		return
+	}
+
+	for _, v := range input {
+		vString := string(v)
+		if vString == strings.ToUpper(vString) {
+			output += "_" + strings.ToLower(vString)
+		} else {
+			output += vString
+		}
+	}
+
+	return
+}
+
+// snakeCase converts a Pascal or camel case identifier to lower snake case.
+// Runs of capitals are treated as a single acronym, so "UserID" becomes
+// "user_id" and "HTTPSProxy" becomes "https_proxy".
+func snakeCase(input string) string {
+	runes := []rune(input)
+	var sb strings.Builder
+
+	for i, r := range runes {
+		if unicode.IsUpper(r) && i > 0 && runes[i-1] != '_' {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				sb.WriteRune('_')
+			}
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+
+	return sb.String()
+}
+
+// pascalToCamel converts a Pascal case string to a camel case string.
+func pascalToCamel(input string) (camelCase string) {
+	if input == "" {
+		return ""
+	}
+
+	camelCase = strings.ToLower(string(input[0]))
+	camelCase += string(input[1:])
+	return camelCase
+}
+
+// primitiveType returns the TypeScript type of a primitive Swagger type.
+func primitiveType(swaggerType string, format string, bigint bool) string {
+	if swaggerType == "integer" {
+		if format == "int64" && bigint {
+			return "bigint"
+		}
+		return "number"
+	}
+	return swaggerType
+}
+
+// parameterType returns the TypeScript type of an operation parameter. Prefix
+// is prepended to referenced definition names.
+func parameterType(parameter Parameter, prefix string, bigint bool) string {
+	switch {
+	case parameter.File:
+		return "File | Blob"
+	case parameter.Multipart:
+		return "FormData"
+	case parameter.In == "body":
+		if parameter.Schema.Type == "string" {
+			return "string"
+		}
+		return prefix + convertRefToClassName(parameter.Schema.Ref)
+	case parameter.Type == "array":
+		return "Array<" + primitiveType(parameter.Items.Type, "", bigint) + ">"
+	case parameter.Type == "object":
+		return "Map<string, " + primitiveType(parameter.AdditionalProperties.Type, "", bigint) + ">"
+	default:
+		return primitiveType(parameter.Type, parameter.Format, bigint)
+	}
+}
+
+// isIdempotent reports whether a request with the given HTTP method can be
+// safely retried. POST requests are only retried when the operation is
+// explicitly tagged with an idempotency key.
+func isIdempotent(method string, idempotencyKey bool) bool {
+	switch strings.ToUpper(method) {
+	case "GET", "HEAD", "PUT", "DELETE":
+		return true
+	case "POST":
+		return idempotencyKey
+	default:
+		return false
+	}
+}
+
+// responseType picks how the body of a successful response is read from the
+// media types an operation produces: "text", "blob" or "json".
+func responseType(produces []string) string {
+	binary := false
+	for _, mediaType := range produces {
+		switch mediaType {
+		case "application/json":
+			return "json"
+		case "application/octet-stream":
+			binary = true
+		}
+	}
+	if binary {
+		return "blob"
+	}
+	if len(produces) == 1 && produces[0] == "text/plain" {
+		return "text"
+	}
+	return "json"
+}
+
+// zodType returns the Zod schema which validates a definition property, using
+// the same mapping as the generated interfaces. References are resolved
+// lazily because the schemas are declared in alphabetical order.
+func zodType(property Property, prefix string, bigint, dates bool) string {
+	primitive := func(typ string) string {
+		switch typ {
+		case "integer", "number":
+			return "z.number()"
+		case "boolean":
+			return "z.boolean()"
+		case "string":
+			return "z.string()"
+		default:
+			return "z.any()"
+		}
+	}
+	ref := func(ref string) string {
+		return "z.lazy(() => " + prefix + convertRefToClassName(ref) + "Schema)"
+	}
+
+	switch property.Type {
+	case "integer":
+		if property.Format == "int64" && bigint {
+			return "z.coerce.bigint()"
+		}
+		return "z.number()"
+	case "number", "boolean":
+		return primitive(property.Type)
+	case "array":
+		if property.Items.Ref != "" {
+			return "z.array(" + ref(property.Items.Ref) + ")"
+		}
+		return "z.array(" + primitive(property.Items.Type) + ")"
+	case "object":
+		return "z.record(" + primitive(property.AdditionalProperties.Type) + ")"
+	case "string":
+		switch property.Format {
+		case "date", "date-time":
+			if dates {
+				return "z.coerce.date()"
+			}
+		case "byte", "binary":
+			return "z.string().transform(base64ToUint8Array)"
+		}
+		return "z.string()"
+	}
+	if property.Ref != "" {
+		return ref(property.Ref)
+	}
+	return "z.any()"
+}
+
+// ioTsType returns the io-ts codec which decodes a definition property, using
+// the same mapping as the generated interfaces.
+func ioTsType(property Property, prefix string, dates, bigints bool) string {
+	primitive := func(typ string) string {
+		switch typ {
+		case "integer", "number":
+			return "t.number"
+		case "boolean":
+			return "t.boolean"
+		case "string":
+			return "t.string"
+		default:
+			return "t.unknown"
+		}
+	}
+
+	switch property.Type {
+	case "integer":
+		if property.Format == "int64" && bigints {
+			return "BigIntFromStringCodec"
+		}
+	case "string":
+		switch property.Format {
+		case "date", "date-time":
+			if dates {
+				return "DateFromStringCodec"
+			}
+		case "byte", "binary":
+			return "Uint8ArrayFromBase64Codec"
+		}
+	case "array":
+		if property.Items.Ref != "" {
+			return "t.array(" + prefix + convertRefToClassName(property.Items.Ref) + "Codec)"
+		}
+		return "t.array(" + primitive(property.Items.Type) + ")"
+	case "object":
+		return "t.record(t.string, " + primitive(property.AdditionalProperties.Type) + ")"
+	case "":
+		if property.Ref != "" {
+			return prefix + convertRefToClassName(property.Ref) + "Codec"
+		}
+	}
+	return primitive(property.Type)
+}
+
+// ioTsCodec returns the io-ts codec of a definition, using t.type for the
+// required and t.partial for the optional properties.
+func ioTsCodec(definition Definition, prefix, indent string, dates, bigints bool) string {
+	if len(definition.Enum) > 0 {
+		literals := make([]string, len(definition.Enum))
+		for i := range definition.Enum {
+			literals[i] = fmt.Sprintf("t.literal(%d)", i)
+		}
+		if len(literals) == 1 {
+			return literals[0]
+		}
+		return "t.union([" + strings.Join(literals, ", ") + "])"
+	}
+
+	var required, optional []string
+	keys := make([]string, 0, len(definition.Properties))
+	for key := range definition.Properties {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		field := camelToSnake(key) + ": " + ioTsType(definition.Properties[key], prefix, dates, bigints) + ","
+		if isRequired(definition, key) {
+			required = append(required, field)
+		} else {
+			optional = append(optional, field)
+		}
+	}
+
+	object := func(kind string, fields []string, depth int) string {
+		prefix := strings.Repeat(indent, depth)
+		var sb strings.Builder
+		sb.WriteString("t." + kind + "({\n")
+		for _, field := range fields {
+			sb.WriteString(prefix + indent + field + "\n")
+		}
+		sb.WriteString(prefix + "})")
+		return sb.String()
+	}
+
+	switch {
+	case len(required) > 0 && len(optional) > 0:
+		return "t.intersection([\n" +
+			indent + object("type", required, 1) + ",\n" +
+			indent + object("partial", optional, 1) + ",\n" +
+			"])"
+	case len(required) > 0:
+		return object("type", required, 0)
+	default:
+		return object("partial", optional, 0)
+	}
+}
+
+// ioTsOrder returns the definition names ordered so that every codec is
+// declared after the codecs it refers to, and alphabetically otherwise.
+func ioTsOrder(definitions map[string]Definition) []string {
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var order []string
+	visited := make(map[string]bool)
+	var visit func(name string)
+	visit = func(name string) {
+		if visited[name] {
+			return
+		}
+		visited[name] = true
+
+		definition, ok := definitions[name]
+		if !ok {
+			return
+		}
+		keys := make([]string, 0, len(definition.Properties))
+		for key := range definition.Properties {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			property := definition.Properties[key]
+			for _, ref := range []string{property.Ref, property.Items.Ref} {
+				if ref != "" {
+					visit(refName(ref))
+				}
+			}
+		}
+		order = append(order, name)
+	}
+
+	for _, name := range names {
+		visit(name)
+	}
+	return order
+}
+
+// jsonConversion returns how convertJson converts the JSON value of a
+// property to its TypeScript type: "date" for date strings when dates is set,
+// "bigint" for int64 integers when bigints is set, "bytes" for base64 encoded
+// strings, the name of the definition
+// it refers to, prefixed with "[]" for arrays, or "" when the value is used as
+// is.
+func jsonConversion(property Property, dates, bigints bool) string {
+	switch {
+	case property.XTsType != "":
+		return ""
+	case property.Type == "string" && (property.Format == "date" || property.Format == "date-time"):
+		if dates {
+			return "date"
+		}
+	case property.Type == "integer" && property.Format == "int64":
+		if bigints {
+			return "bigint"
+		}
+	case property.Type == "string" && (property.Format == "byte" || property.Format == "binary"):
+		return "bytes"
+	case property.Type == "array" && property.Items.Ref != "":
+		return "[]" + refName(property.Items.Ref)
+	case property.Ref != "":
+		return refName(property.Ref)
+	}
+	return ""
+}
+
+// jsonConversions returns the fields to convert of each definition, by their
+// JSON names. A reference is only converted when the definition it refers to
+// has fields to convert, and definitions without any are left out.
+func jsonConversions(definitions map[string]Definition, dates, bigints bool) map[string]map[string]string {
+	conversions := make(map[string]map[string]string)
+	// a reference can only be resolved once the definition it refers to was
+	// seen, so repeat until nothing is added.
+	for changed := true; changed; {
+		changed = false
+		for name, definition := range definitions {
+			for key, property := range definition.Properties {
+				conversion := jsonConversion(property, dates, bigints)
+				field := camelToSnake(key)
+				if conversion == "" || conversions[name][field] != "" {
+					continue
+				}
+				switch target := strings.TrimPrefix(conversion, "[]"); target {
+				case "date", "bigint", "bytes":
+				default:
+					if conversions[target] == nil {
+						continue
+					}
+				}
+				if conversions[name] == nil {
+					conversions[name] = make(map[string]string)
+				}
+				conversions[name][field] = conversion
+				changed = true
+			}
+		}
+	}
+	return conversions
+}
+
+// hasBytes reports whether any definition has a field with the "byte" or
+// "binary" string format, which needs the base64 helpers.
+func hasBytes(definitions map[string]Definition) bool {
+	for _, definition := range definitions {
+		for _, property := range definition.Properties {
+			if property.Type == "string" && (property.Format == "byte" || property.Format == "binary") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// hasInt64 reports whether any definition has an int64 integer property,
+// whose bigint values are converted to and from JSON strings.
+func hasInt64(definitions map[string]Definition) bool {
+	for _, definition := range definitions {
+		for _, property := range definition.Properties {
+			if property.Type == "integer" && property.Format == "int64" && property.XTsType == "" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// ioTsRecursive returns the names of the definitions which refer to
+// themselves, directly or through other definitions. Their codecs are
+// declared with t.recursion, because ioTsOrder cannot declare them after the
+// codecs they refer to.
+func ioTsRecursive(definitions map[string]Definition) map[string]bool {
+	refs := func(name string) []string {
+		var names []string
+		for _, property := range definitions[name].Properties {
+			for _, ref := range []string{property.Ref, property.Items.Ref} {
+				if ref != "" {
+					names = append(names, refName(ref))
+				}
+			}
+		}
+		return names
+	}
+
+	recursive := make(map[string]bool)
+	for name := range definitions {
+		seen := make(map[string]bool)
+		stack := refs(name)
+		for len(stack) > 0 && !recursive[name] {
+			next := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if next == name {
+				recursive[name] = true
+			} else if !seen[next] {
+				seen[next] = true
+				stack = append(stack, refs(next)...)
+			}
+		}
+	}
+	return recursive
+}
+
+// defaultValue returns the default of a property as a TypeScript expression
+// of the field's type, or "" when it has none.
+func defaultValue(property Property, bigint, dates bool) string {
+	if len(property.Default) == 0 {
+		return ""
+	}
+
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, property.Default); err != nil {
+		return ""
+	}
+	value := buf.String()
+	switch {
+	case property.Type == "integer" && property.Format == "int64" && bigint:
+		return "BigInt(" + value + ")"
+	case property.Type == "integer" && property.Format == "int64":
+		// int64 defaults are strings like the values the server sends.
+		return strings.Trim(value, `"`)
+	case property.Type == "string" && (property.Format == "date" || property.Format == "date-time") && dates:
+		return "new Date(" + value + ")"
+	case property.Type == "string" && (property.Format == "byte" || property.Format == "binary"):
+		return "base64ToUint8Array(" + value + ")"
+	}
+	return value
+}
+
+// headerField converts a header name such as "X-Cursor-Next" into the name of
+// the field it is read into, e.g. "xCursorNext".
+func headerField(header string) string {
+	return escapeReserved(snakeToCamel(strings.ToLower(strings.ReplaceAll(header, "-", "_"))))
+}
+
+// headerType returns the TypeScript type of a response header.
+func headerType(header HeaderDefinition, bigint bool) string {
+	switch header.Type {
+	case "integer", "number", "boolean":
+		return primitiveType(header.Type, header.Format, bigint)
+	default:
+		return "string"
+	}
+}
+
+// headerValue returns the TypeScript expression which converts the string
+// value of a response header, held in the variable named value, to its type.
+func headerValue(header HeaderDefinition, value string, bigint bool) string {
+	switch headerType(header, bigint) {
+	case "bigint":
+		return "BigInt(" + value + ")"
+	case "number":
+		return "Number(" + value + ")"
+	case "boolean":
+		return value + ` === "true"`
+	default:
+		return value
+	}
+}
+
+// constraintTags returns the validation keywords of a property or parameter
+// as JSDoc tags, e.g. "@minimum 0 @maximum 100", or "" when it has none.
+func constraintTags(c Constraints) string {
+	var tags []string
+	if c.Minimum != nil {
+		tag := "@minimum "
+		if c.ExclusiveMinimum {
+			tag = "@exclusiveMinimum "
+		}
+		tags = append(tags, tag+strconv.FormatFloat(*c.Minimum, 'f', -1, 64))
+	}
+	if c.Maximum != nil {
+		tag := "@maximum "
+		if c.ExclusiveMaximum {
+			tag = "@exclusiveMaximum "
+		}
+		tags = append(tags, tag+strconv.FormatFloat(*c.Maximum, 'f', -1, 64))
+	}
+	if c.Pattern != "" {
+		// a pattern must not end the comment it is written into.
+		tags = append(tags, "@pattern "+strings.ReplaceAll(c.Pattern, "*/", "*\\/"))
+	}
+	if c.MinLength != nil {
+		tags = append(tags, "@minLength "+strconv.Itoa(*c.MinLength))
+	}
+	if c.MaxLength != nil {
+		tags = append(tags, "@maxLength "+strconv.Itoa(*c.MaxLength))
+	}
+	return strings.Join(tags, " ")
+}
+
+// IntegerEnum is an enum emitted for integer properties with an enum.
+type IntegerEnum struct {
+	Name   string
+	Names  []string
+	Values []string
+}
+
+// integerEnumName returns the name of the enum emitted for an integer
+// property whose enum values are named with x-enum-names, derived from its
+// key, or "" for any other property.
+func integerEnumName(key string, property Property) string {
+	if property.Type != "integer" || len(property.Enum) == 0 || len(property.XEnumNames) != len(property.Enum) {
+		return ""
+	}
+	return camelToPascal(snakeToCamel(key))
+}
+
+// enumMemberNames returns the names of the values of an integer enum: its
+// x-enum-varnames when they name every value, else its x-enum-names.
+func enumMemberNames(property Property) []string {
+	if len(property.XEnumVarnames) == len(property.Enum) {
+		return property.XEnumVarnames
+	}
+	return property.XEnumNames
+}
+
+// integerEnums returns the enums of the integer properties named with
+// x-enum-names, sorted by name. Properties with the same key share one enum,
+// so it is an error for their values or names to differ, and for an enum to
+// be named like a definition.
+func integerEnums(definitions map[string]Definition) ([]IntegerEnum, error) {
+	classes := make(map[string]string)
+	names := make([]string, 0, len(definitions))
+	for name := range definitions {
+		classes[convertRefToClassName(name)] = name
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	byName := make(map[string]IntegerEnum)
+	owners := make(map[string]string)
+	for _, definitionName := range names {
+		definition := definitions[definitionName]
+		keys := make([]string, 0, len(definition.Properties))
+		for key := range definition.Properties {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+
+		for _, key := range keys {
+			property := definition.Properties[key]
+			name := integerEnumName(key, property)
+			if name == "" {
+				continue
+			}
+			owner := definitionName + "." + key
+			if other, ok := classes[name]; ok {
+				return nil, fmt.Errorf("Enum %s of %s would have the name of definition %s.", name, owner, other)
+			}
+			enum := IntegerEnum{Name: name, Names: enumMemberNames(property)}
+			for _, value := range property.Enum {
+				enum.Values = append(enum.Values, string(value))
+			}
+			if other, ok := byName[name]; ok {
+				if strings.Join(other.Names, ",") != strings.Join(enum.Names, ",") || strings.Join(other.Values, ",") != strings.Join(enum.Values, ",") {
+					return nil, fmt.Errorf("Enum %s of %s differs from the one of %s.", name, owner, owners[name])
+				}
+				continue
+			}
+			byName[name] = enum
+			owners[name] = owner
+		}
+	}
+
+	enums := make([]IntegerEnum, 0, len(byName))
+	for _, enum := range byName {
+		enums = append(enums, enum)
+	}
+	sort.Slice(enums, func(i, j int) bool { return enums[i].Name < enums[j].Name })
+	return enums, nil
+}
+
+// guardCheck returns the check a type guard makes of the type of a required
+// field, or "" when only its presence is checked. int64 values may be sent
+// as strings.
+func guardCheck(property Property, key string) string {
+	value := "obj[" + strconv.Quote(key) + "]"
+	switch {
+	case property.Ref != "" || property.XTsType != "":
+		return ""
+	case property.Type == "string" && (property.Format == "date" || property.Format == "date-time"):
+		return "(typeof " + value + ` === "string" || ` + value + " instanceof Date)"
+	case property.Format == "int64" || property.Format == "uint64":
+		return "(typeof " + value + ` === "string" || typeof ` + value + ` === "number" || typeof ` + value + ` === "bigint")`
+	case property.Type == "string":
+		return "typeof " + value + ` === "string"`
+	case property.Type == "boolean":
+		return "typeof " + value + ` === "boolean"`
+	case property.Type == "integer" || property.Type == "number":
+		return "typeof " + value + ` === "number"`
+	case property.Type == "array":
+		return "Array.isArray(" + value + ")"
+	case property.Type == "object":
+		return "typeof " + value + ` === "object"`
+	default:
+		return ""
+	}
+}
+
+// isRequired reports whether a definition lists the property as required.
+func isRequired(definition Definition, key string) bool {
+	for _, name := range definition.Required {
+		if name == key {
+			return true
+		}
+	}
+	return false
+}
+
+// returnType returns the TypeScript type an API method resolves to. Prefix
+// is prepended to referenced definition names.
+func returnType(operation Operation, prefix string) string {
+	switch responseType(operation.Produces) {
+	case "text":
+		return "string"
+	case "blob":
+		return "Blob"
+	}
+
+	schema := operation.Responses.Ok.Schema
+	switch {
+	case schema.Ref != "":
+		return prefix + convertRefToClassName(schema.Ref)
+	case schema.Type == "":
+		return "void"
+	default:
+		return "any"
+	}
+}
+
+// credentials returns the names of the credential parameters an API method
+// takes before the operation's own parameters.
+func credentials(operation Operation) []string {
+	if operation.XNakamaSse {
+		// EventSource cannot send an Authorization header.
+		return nil
+	}
+	if len(operation.Security) == 0 {
+		return []string{"bearerToken"}
+	}
+
+	var names []string
+	for _, security := range operation.Security {
+		keys := make([]string, 0, len(security))
+		for key := range security {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			switch key {
+			case "BasicAuth", "HttpKeyAuth":
+				names = append(names, "basicAuthUsername", "basicAuthPassword")
+			case "BearerJwt":
+				names = append(names, "bearerToken")
+			}
+		}
+	}
+	return names
+}
+
+// operationParameters returns the parameter declarations of an API method,
+// without its options, e.g. "bearerToken: string" and "limit?: number". With
+// requestTypes, the parameters outside the path are declared as a single
+// request object instead.
+func operationParameters(operation Operation, prefix string, bigint, requestTypes bool) []string {
+	var declarations []string
+	for _, name := range credentials(operation) {
+		declarations = append(declarations, name+": string")
+	}
+	for _, parameter := range operation.Parameters {
+		if requestTypes && parameter.In != "path" {
+			continue
+		}
+		optional := ""
+		if !parameter.Required {
+			optional = "?"
+		}
+		declarations = append(declarations, escapeReserved(snakeToCamel(parameter.Name))+optional+": "+parameterType(parameter, prefix, bigint))
+	}
+	if requestTypes && len(requestParameters(operation)) > 0 {
+		declaration := "request: " + prefix + camelToPascal(snakeToCamel(stripOperationPrefix(operation.Name()))) + "Request"
+		if !requiresRequest(operation) {
+			declaration += " = {}"
+		}
+		declarations = append(declarations, declaration)
+	}
+	return declarations
+}
+
+// operationArguments returns the names of the parameters declared by
+// operationParameters, to pass them on to the API method.
+func operationArguments(operation Operation, requestTypes bool) []string {
+	names := credentials(operation)
+	for _, parameter := range operation.Parameters {
+		if requestTypes && parameter.In != "path" {
+			continue
+		}
+		names = append(names, escapeReserved(snakeToCamel(parameter.Name)))
+	}
+	if requestTypes && len(requestParameters(operation)) > 0 {
+		names = append(names, "request")
+	}
+	return names
+}
+
+// requestParameters returns the parameters of an operation which are not in
+// its path, the fields of its request interface with --emit-request-types.
+func requestParameters(operation Operation) []Parameter {
+	var parameters []Parameter
+	for _, parameter := range operation.Parameters {
+		if parameter.In != "path" {
+			parameters = append(parameters, parameter)
+		}
+	}
+	return parameters
+}
+
+// requiresRequest reports whether any field of the request interface of an
+// operation is required, so the request object cannot default to {}.
+func requiresRequest(operation Operation) bool {
+	for _, parameter := range requestParameters(operation) {
+		if parameter.Required {
+			return true
+		}
+	}
+	return false
+}
+
+// uploadsFile reports whether an operation sends a file in its request body.
+func uploadsFile(operation Operation) bool {
+	for _, parameter := range operation.Parameters {
+		if parameter.File || parameter.Multipart {
+			return true
+		}
+	}
+	return false
+}
+
+// consumesMultipart reports whether an operation takes a multipart/form-data
+// request body.
+func consumesMultipart(operation Operation) bool {
+	for _, consumes := range operation.Consumes {
+		if consumes == "multipart/form-data" {
+			return true
+		}
+	}
+	return false
+}
+
+// multipartParameters marks the body parameter of a multipart/form-data
+// operation, which callers pass as FormData. An operation declaring neither a
+// body nor "formData" parameters gets a required body parameter.
+func multipartParameters(parameters []Parameter) []Parameter {
+	declared := false
+	for i, parameter := range parameters {
+		switch parameter.In {
+		case "body":
+			parameters[i].Multipart = true
+			declared = true
+		case "formData":
+			declared = true
+		}
+	}
+	if !declared {
+		parameters = append(parameters, Parameter{Name: "body", In: "body", Required: true, Multipart: true})
+	}
+	return parameters
+}
+
+// pathPattern converts a templated API path such as "/v2/user/{id}" into the
+// source of a JavaScript regular expression which matches concrete paths. The
+// pattern is not anchored at the start, so that it also matches below the
+// path of a basePath such as "https://example.com/nakama".
+func pathPattern(url string) string {
+	var sb strings.Builder
+	for _, segment := range strings.Split(url, "/") {
+		if segment == "" {
+			continue
+		}
+		sb.WriteString(`\/`)
+		if strings.HasPrefix(segment, "{") && strings.HasSuffix(segment, "}") {
+			sb.WriteString(`[^/]+`)
+		} else {
+			sb.WriteString(regexp.QuoteMeta(segment))
+		}
+	}
+	return sb.String()
+}
+
+var nonIdentifier = regexp.MustCompile(`[^A-Za-z0-9_]+`)
+
+// reservedWords are the JavaScript and TypeScript keywords which cannot be
+// used as the name of a parameter or variable.
+var reservedWords = map[string]bool{
+	"await": true, "break": true, "case": true, "catch": true, "class": true,
+	"const": true, "continue": true, "debugger": true, "default": true,
+	"delete": true, "do": true, "else": true, "enum": true, "export": true,
+	"extends": true, "false": true, "finally": true, "for": true,
+	"function": true, "if": true, "implements": true, "import": true,
+	"in": true, "instanceof": true, "interface": true, "let": true,
+	"new": true, "null": true, "package": true, "private": true,
+	"protected": true, "public": true, "return": true, "static": true,
+	"super": true, "switch": true, "this": true, "throw": true, "true": true,
+	"try": true, "type": true, "typeof": true, "var": true, "void": true,
+	"while": true, "with": true, "yield": true,
+}
+
+// escapeReserved appends an underscore to identifiers which are reserved
+// words, e.g. "type" becomes "type_".
+func escapeReserved(identifier string) string {
+	if reservedWords[identifier] {
+		return identifier + "_"
+	}
+	return identifier
+}
+
+// OperationRef is an operation together with the path and method it is
+// served at.
+type OperationRef struct {
+	Url    string
+	Method string
+	Operation
+}
+
+// operations returns all operations sorted by path and method.
+func operations(paths map[string]map[string]Operation) []OperationRef {
+	var refs []OperationRef
+	for url, path := range paths {
+		for method, operation := range path {
+			refs = append(refs, OperationRef{Url: url, Method: method, Operation: operation})
+		}
+	}
+	sort.Slice(refs, func(i, j int) bool {
+		if refs[i].Url != refs[j].Url {
+			return refs[i].Url < refs[j].Url
+		}
+		return refs[i].Method < refs[j].Method
+	})
+	return refs
+}
+
+// queryParameters returns the query parameters of an operation.
+func queryParameters(operation Operation) []Parameter {
+	var parameters []Parameter
+	for _, parameter := range operation.Parameters {
+		if parameter.In == "query" {
+			parameters = append(parameters, parameter)
+		}
+	}
+	return parameters
+}
+
+// formParameters returns the multipart/form-data fields of an operation.
+func formParameters(operation Operation) []Parameter {
+	var parameters []Parameter
+	for _, parameter := range operation.Parameters {
+		if parameter.In == "formData" || parameter.Multipart {
+			parameters = append(parameters, parameter)
+		}
+	}
+	return parameters
+}
+
+// TagGroup is the operations with the same first tag.
+type TagGroup struct {
+	Tag        string
+	Operations []OperationRef
+}
+
+// healthOperation returns the operation polled by --emit-health-check: the
+// first one with the x-nakama-health extension, or else the first one served
+// at a /healthcheck path. Operations with required parameters other than
+// credentials cannot be polled.
+func healthOperation(paths map[string]map[string]Operation) (OperationRef, bool) {
+	var found OperationRef
+	ok := false
+	for _, ref := range operations(paths) {
+		if ref.XNakamaSse || requiresParameters(ref.Operation) {
+			continue
+		}
+		if ref.XNakamaHealth {
+			return ref, true
+		}
+		if !ok && strings.HasSuffix(ref.Url, "/healthcheck") {
+			found, ok = ref, true
+		}
+	}
+	return found, ok
+}
+
+// offlineQueued returns the operations which --emit-offline-queue stores
+// while the device is offline: those marked x-nakama-offline-safe, except
+// event streams, file uploads, whose arguments cannot be stored in
+// localStorage, and operations using basic auth, whose password would be.
+func offlineQueued(paths map[string]map[string]Operation) []OperationRef {
+	var refs []OperationRef
+	for _, ref := range operations(paths) {
+		if !ref.XNakamaOfflineSafe || ref.XNakamaSse || uploadsFile(ref.Operation) {
+			continue
+		}
+		if names := credentials(ref.Operation); len(names) > 1 || len(names) == 1 && names[0] != "bearerToken" {
+			continue
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// requiresParameters reports whether an operation has required parameters.
+func requiresParameters(operation Operation) bool {
+	for _, parameter := range operation.Parameters {
+		if parameter.Required {
+			return true
+		}
+	}
+	return false
+}
+
+// tagGroups returns the operations grouped by their first tag, sorted by tag.
+func tagGroups(paths map[string]map[string]Operation) []TagGroup {
+	var groups []TagGroup
+	for tag, paths := range groupByTag(paths) {
+		groups = append(groups, TagGroup{Tag: tag, Operations: operations(paths)})
+	}
+	sort.Slice(groups, func(i, j int) bool { return groups[i].Tag < groups[j].Tag })
+	return groups
+}
+
+// classMembers are the members of the API class besides its operations.
+var classMembers = []string{"constructor", "serverKey", "apiKey", "basePath", "timeoutMs", "configuration", "circuit", "inflight", "doFetch", "dedup", "signRequest", "traced", "doXhr", "buildFullUrl"}
+
+// methodName returns the name of the API method of an operation, e.g.
+// "getAccount" or "subscribeStreamEvents" for an event stream.
+func methodName(operation Operation) string {
+	name := snakeToCamel(stripOperationPrefix(operation.Name()))
+	if operation.XNakamaSse {
+		return "subscribe" + camelToPascal(name)
+	}
+	return escapeReserved(name)
+}
+
+// checkTagProperties reports a --group-by-tag object which would share its
+// name with another tag's object, a member of the API class or a method.
+func checkTagProperties(paths map[string]map[string]Operation) error {
+	members := make(map[string]string)
+	for _, member := range classMembers {
+		members[member] = "a member of the API class"
+	}
+	for _, ref := range operations(paths) {
+		members[methodName(ref.Operation)] = "the method of " + ref.OperationId
+	}
+	for _, group := range tagGroups(paths) {
+		property := tagProperty(group.Tag)
+		if other, ok := members[property]; ok {
+			return fmt.Errorf("The object of tag %q would be named %s like %s.", group.Tag, property, other)
+		}
+		members[property] = fmt.Sprintf("the object of tag %q", group.Tag)
+	}
+	return nil
+}
+
+// operationVariables returns the path and required query parameters of an
+// operation.
+func operationVariables(operation Operation) []Parameter {
+	var parameters []Parameter
+	for _, parameter := range operation.Parameters {
+		if parameter.In == "path" || parameter.In == "query" && parameter.Required {
+			parameters = append(parameters, parameter)
+		}
+	}
+	return parameters
+}
+
+// requiredParameters returns the path and required query parameters of all
+// operations, sorted and unique by name.
+func requiredParameters(paths map[string]map[string]Operation) []Parameter {
+	seen := make(map[string]bool)
+	var parameters []Parameter
+	for _, operation := range operations(paths) {
+		for _, parameter := range operationVariables(operation.Operation) {
+			if !seen[parameter.Name] {
+				seen[parameter.Name] = true
+				parameters = append(parameters, parameter)
+			}
+		}
+	}
+	sort.Slice(parameters, func(i, j int) bool { return parameters[i].Name < parameters[j].Name })
+	return parameters
+}
+
+// placeholder returns a value of the type of a parameter to fill in.
+func placeholder(parameter Parameter) string {
+	switch parameter.Type {
+	case "boolean":
+		return "false"
+	case "integer", "number":
+		return "0"
+	default:
+		return parameter.Name
+	}
+}
+
+// variableQuery returns the query string of the required query parameters of
+// an operation with {{variable}} placeholders, e.g. "?id={{id}}".
+func variableQuery(operation Operation) string {
+	var query []string
+	for _, parameter := range queryParameters(operation) {
+		if parameter.Required {
+			query = append(query, parameter.Name+"={{"+parameter.Name+"}}")
+		}
+	}
+	if len(query) == 0 {
+		return ""
+	}
+	return "?" + strings.Join(query, "&")
+}
+
+// markdownCell escapes text for a Markdown table cell.
+func markdownCell(text string) string {
+	return strings.NewReplacer("|", "\\|", "\n", " ").Replace(text)
+}
+
+// markdownCode formats text as inline code in a Markdown table cell.
+func markdownCode(text string) string {
+	return "`" + markdownCell(text) + "`"
+}
+
+// markdownLink links a definition name to its section of the Markdown
+// reference.
+func markdownLink(name string) string {
+	return "[" + name + "](#" + strings.ToLower(name) + ")"
+}
+
+// variableUrl rewrites the path parameters of a templated API path as
+// {{variable}} placeholders, e.g. "/v2/user/{id}" becomes "/v2/user/{{id}}".
+func variableUrl(url string) string {
+	return pathParameter.ReplaceAllString(url, "{{$1}}")
+}
+
+// variablePath splits a templated API path into segments with {{variable}}
+// placeholders.
+func variablePath(url string) []string {
+	return strings.Split(strings.TrimPrefix(variableUrl(url), "/"), "/")
+}
+
+// pathVariables returns the sorted names of all path parameters.
+func pathVariables(paths map[string]map[string]Operation) []string {
+	seen := make(map[string]bool)
+	var names []string
+	for url := range paths {
+		for _, match := range pathParameter.FindAllStringSubmatch(url, -1) {
+			if !seen[match[1]] {
+				seen[match[1]] = true
+				names = append(names, match[1])
+			}
+		}
+	}
+	sort.Strings(names)
+	return names
+}
+
+// authorizationPlaceholder returns the Authorization header of an operation
+// with a {{bearerToken}} or {{basicAuth}} placeholder for the credentials.
+func authorizationPlaceholder(operation Operation) string {
+	for _, credential := range credentials(operation) {
+		if credential == "basicAuthUsername" {
+			return "Basic {{basicAuth}}"
+		}
+	}
+	return "Bearer {{bearerToken}}"
+}
+
+// exampleValue returns a placeholder value of a property, expanding
+// referenced definitions up to depth levels.
+func exampleValue(property Property, definitions map[string]Definition, depth int) interface{} {
+	switch {
+	case property.Ref != "":
+		return exampleDefinition(refName(property.Ref), definitions, depth)
+	case property.Type == "array":
+		if property.Items.Ref != "" && depth > 0 {
+			return []interface{}{exampleDefinition(refName(property.Items.Ref), definitions, depth-1)}
+		}
+		return []interface{}{}
+	case property.Type == "object":
+		return map[string]interface{}{}
+	case property.Type == "boolean":
+		return false
+	case property.Type == "integer" && property.Format == "int64":
+		return "0"
+	case property.Type == "integer" || property.Type == "number":
+		return 0
+	default:
+		return ""
+	}
+}
+
+// exampleDefinition returns a placeholder value of a definition: its first
+// value for enums, or an object with a placeholder for each property.
+func exampleDefinition(name string, definitions map[string]Definition, depth int) interface{} {
+	definition, ok := definitions[name]
+	switch {
+	case !ok:
+		return nil
+	case len(definition.Enum) > 0:
+		return definition.Enum[0]
+	case depth <= 0:
+		return map[string]interface{}{}
+	}
+
+	value := make(map[string]interface{}, len(definition.Properties))
+	for key, property := range definition.Properties {
+		if !property.ReadOnly {
+			value[key] = exampleValue(property, definitions, depth-1)
+		}
+	}
+	return value
+}
+
+func replace(input, from, to string) string {
+	return strings.Replace(input, from, to, -1)
+}
+
+// Config holds the options of a generator run, usually set from the command
+// line by NewConfig.
+type Config struct {
+	Input     string // a file path, an http:// or https:// URL or "-" for stdin
+	Namespace string
+	Output    string
+	OutputDir string
+
+	DateReviver            bool
+	NoBigint               bool
+	EmitCloudRun           bool
+	Tags                   []string
+	PathPrefixes           []string
+	OmitDeprecated         bool
+	PruneUnused            bool
+	Verbose                bool
+	Quiet                  bool
+	SplitByTag             bool
+	Workers                int // files rendered in parallel with SplitByTag
+	FetchTimeout           time.Duration
+	Insecure               bool
+	Indent                 string
+	Strict                 bool
+	EmitZod                bool
+	EmitIoTs               bool
+	Prefix                 string
+	TsNamespace            string
+	EmitDefaults           bool
+	EmitOtel               bool
+	EmitReactHooks         bool
+	EmitVueComposables     bool
+	EmitRxjs               bool
+	EmitAngular            bool
+	NoConstEnum            bool
+	EmitTypeGuards         bool
+	ValidateResponses      bool
+	Merge                  []string
+	ConflictStrategy       string
+	Compare                string
+	ChangelogOut           string
+	EmitMock               bool
+	ModuleFormat           string
+	EmitIndex              string
+	EmitServiceWorkerCache bool
+	EmitPostman            string // a Postman collection file
+	EmitHttp               string // a JetBrains HTTP Client requests file
+	EmitBruno              string // a directory for a Bruno collection
+	EmitDocs               string // a Markdown API reference file
+	EmitPackageJson        string
+	EmitTsconfig           string
+	SkipUnchanged          bool
+	NoBanner               bool
+	ImportType             bool
+	EmitRequestTypes       bool
+	EmitResponseTypes      bool
+	GroupByTag             bool
+	EmitDedup              bool
+	EmitOfflineQueue       bool
+	EmitHealthCheck        bool
+	Tsconfig               string // the project's tsconfig.json
+	ConfigFile             string `json:"-"` // a JSON file of options, overridden by flags
+
+	namespaceGiven bool // set when the namespace argument is present, even if empty
+}
+
+// configFile is read for options when present in the working directory and
+// no --config flag is given.
+const configFile = ".nakamarc"
+
+// NewConfig parses the command line flags and arguments, and the options of
+// the config file, into a Config. It exits on malformed flags, like
+// flag.Parse, or an unreadable config file.
+func NewConfig() Config {
+	cfg, err := newConfig(flag.CommandLine, os.Args[1:])
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(2)
+	}
+	return cfg
+}
+
+// newConfig parses args with the given flag set, which is left usable for
+// printing the defaults.
+func newConfig(fs *flag.FlagSet, args []string) (Config, error) {
+	var cfg Config
+	fs.StringVar(&cfg.Output, "output", "", "The output for generated code.")
+	fs.BoolVar(&cfg.DateReviver, "date-reviver", false, "Convert ISO 8601 date strings in responses into Date objects.")
+	fs.BoolVar(&cfg.NoBigint, "no-bigint", false, "Emit number instead of bigint for int64 integers, for environments without BigInt.")
+	fs.BoolVar(&cfg.EmitCloudRun, "emit-cloud-run", false, "Also emit a Cloud Run Job entrypoint (main.ts) next to the output.")
+	fs.Var((*stringList)(&cfg.Tags), "tag", "Only include operations with this tag. Can be repeated.")
+	fs.Var((*stringList)(&cfg.PathPrefixes), "path-prefix", "Only include paths starting with this prefix. Can be repeated.")
+	fs.BoolVar(&cfg.OmitDeprecated, "omit-deprecated", false, "Leave deprecated operations and fields out of the output.")
+	fs.BoolVar(&cfg.PruneUnused, "prune-unused", false, "Leave definitions which no operation refers to out of the output.")
+	fs.BoolVar(&cfg.Verbose, "verbose", false, "Log details of the generation to stderr.")
+	fs.BoolVar(&cfg.Quiet, "quiet", false, "Print nothing but errors and the generated code or comparison.")
+	fs.BoolVar(&cfg.SplitByTag, "split-by-tag", false, "Write one file per API tag into the output directory.")
+	fs.StringVar(&cfg.OutputDir, "output-dir", "", "The output directory used with --split-by-tag.")
+	fs.IntVar(&cfg.Workers, "workers", runtime.NumCPU(), "The number of files rendered in parallel with --split-by-tag.")
+	fs.DurationVar(&cfg.FetchTimeout, "fetch-timeout", 10*time.Second, "The timeout for fetching an http:// or https:// input.")
+	fs.BoolVar(&cfg.Insecure, "insecure", false, "Skip TLS certificate verification when fetching an https:// input.")
+	fs.StringVar(&cfg.Indent, "indent", "  ", "One level of indentation in the generated code, or \"tab\".")
+	fs.BoolVar(&cfg.Strict, "strict", false, "Emit required definition fields as non-optional.")
+	fs.BoolVar(&cfg.EmitZod, "emit-zod", false, "Also emit a Zod schema for each definition.")
+	fs.BoolVar(&cfg.EmitIoTs, "emit-io-ts", false, "Also emit an io-ts codec for each definition.")
+	fs.StringVar(&cfg.Prefix, "prefix", "", "Prepend this to the API class and definition type names.")
+	fs.StringVar(&cfg.TsNamespace, "namespace", "", "Wrap the generated code in an exported TypeScript namespace with this name.")
+	fs.BoolVar(&cfg.EmitDefaults, "emit-defaults", false, "Also emit the default values of definitions and of ConfigurationParameters.")
+	fs.BoolVar(&cfg.EmitOtel, "emit-otel", false, "Also emit OpenTelemetry tracing of requests, enabled by a tracer in ConfigurationParameters.")
+	fs.BoolVar(&cfg.EmitReactHooks, "emit-react-hooks", false, "Also emit a React hook for each operation.")
+	fs.BoolVar(&cfg.EmitVueComposables, "emit-vue-composables", false, "Also emit a Vue 3 composable for each operation.")
+	fs.BoolVar(&cfg.EmitRxjs, "emit-rxjs", false, "Also emit a function returning an RxJS Observable for each operation.")
+	fs.BoolVar(&cfg.EmitAngular, "emit-angular", false, "Also emit an injectable Angular service wrapping the API client.")
+	fs.BoolVar(&cfg.NoConstEnum, "no-const-enum", false, "Emit integer enums named with x-enum-names as plain instead of const enums.")
+	fs.BoolVar(&cfg.EmitTypeGuards, "emit-type-guards", false, "Also emit an is<Interface> type guard function for each definition.")
+	fs.BoolVar(&cfg.ValidateResponses, "validate-responses", false, "Parse each response with the Zod schema of its definition. Requires --emit-zod.")
+	fs.Var((*stringList)(&cfg.Merge), "merge", "Merge the paths and definitions of this specification into the input. Can be repeated.")
+	fs.StringVar(&cfg.ConflictStrategy, "conflict-strategy", "error", "How to merge paths and definitions defined differently in several specifications: first, last or error.")
+	fs.StringVar(&cfg.Compare, "compare", "", "Report the changes from this older specification to the input instead of generating code.")
+	fs.StringVar(&cfg.ChangelogOut, "changelog-out", "", "With --compare, also write the operation changes as a Markdown changelog to this file.")
+	fs.BoolVar(&cfg.EmitMock, "emit-mock", false, "Also emit a createMock<Namespace>Api factory for unit tests.")
+	fs.StringVar(&cfg.ModuleFormat, "module-format", "esm", "The module format the generated code is compiled to with --emit-tsconfig or --emit-package-json: esm, cjs or umd.")
+	fs.StringVar(&cfg.EmitIndex, "emit-index", "", "Also emit an index file re-exporting the generated symbols to this path.")
+	fs.BoolVar(&cfg.EmitServiceWorkerCache, "emit-service-worker-cache", false, "Also emit an offline cache service worker (nakama-sw.ts) next to the output.")
+	fs.StringVar(&cfg.EmitPostman, "emit-postman", "", "Also write a Postman collection with a request for each operation to this file.")
+	fs.StringVar(&cfg.EmitHttp, "emit-http", "", "Also write a JetBrains HTTP Client file with a request for each operation to this file.")
+	fs.StringVar(&cfg.EmitBruno, "emit-bruno", "", "Also write a Bruno collection with a request file for each operation into this directory.")
+	fs.StringVar(&cfg.EmitDocs, "emit-docs", "", "Also write a Markdown reference of the operations and definitions to this file.")
+	fs.StringVar(&cfg.EmitPackageJson, "emit-package-json", "", "Also emit a package.json for publishing the generated client to this path.")
+	fs.StringVar(&cfg.EmitTsconfig, "emit-tsconfig", "", "Also emit a tsconfig.json for compiling the generated client to this path.")
+	fs.BoolVar(&cfg.SkipUnchanged, "skip-unchanged", false, "Skip writing the output when neither it nor the options changed since the last run.")
+	fs.BoolVar(&cfg.NoBanner, "no-banner", false, "Leave the \"DO NOT EDIT\" header out of the generated code.")
+	fs.BoolVar(&cfg.ImportType, "import-type", false, "Import interfaces with \"import type\" in the --split-by-tag files. Requires TypeScript 3.8.")
+	fs.BoolVar(&cfg.EmitRequestTypes, "emit-request-types", false, "Pass the parameters of each operation, other than its path parameters, in a single request object with its own interface.")
+	fs.BoolVar(&cfg.EmitResponseTypes, "emit-response-types", false, "Resolve each API method with an object holding the body, status and headers of the response.")
+	fs.BoolVar(&cfg.GroupByTag, "group-by-tag", false, "Also group the methods of the API class in an object per tag, e.g. api.authentication.authenticateEmail.")
+	fs.BoolVar(&cfg.EmitDedup, "emit-dedup", false, "Share the promise of a GET request still in flight with identical calls instead of sending them.")
+	fs.BoolVar(&cfg.EmitOfflineQueue, "emit-offline-queue", false, "Also emit an offline queue class which stores the x-nakama-offline-safe operations while offline and replays them on reconnect.")
+	fs.BoolVar(&cfg.EmitHealthCheck, "emit-health-check", false, "Also emit a health checker class which polls the /healthcheck or x-nakama-health operation and dispatches events when the server's state changes.")
+	fs.StringVar(&cfg.Tsconfig, "tsconfig", "", "The project's tsconfig.json. Enables --import-type when it sets isolatedModules.")
+	fs.StringVar(&cfg.ConfigFile, "config", "", "Read options from this JSON file, "+configFile+" by default. Flags take precedence.")
+	if err := fs.Parse(args); err != nil {
+		return cfg, err
+	}
+
+	positional := fs.Args()
+	path := cfg.ConfigFile
+	if _, err := os.Stat(configFile); path == "" && err == nil {
+		path = configFile
+	}
+	if path != "" {
+		fileArgs, err := readConfigFile(fs, path)
+		if err != nil {
+			return cfg, err
+		}
+		if len(positional) == 0 {
+			positional = fileArgs
+		}
+	}
+
+	if len(positional) > 0 {
+		cfg.Input = positional[0]
+	}
+	if len(positional) > 1 {
+		cfg.Namespace = positional[1]
+		cfg.namespaceGiven = true
+	}
+	return cfg, nil
+}
+
+// readConfigFile applies the options of a config file to the flags of fs
+// which were not given on the command line, and returns the positional
+// arguments of the file. Its keys are flag names, with arrays for the flags
+// which can be repeated, and "args" for the positional arguments.
+func readConfigFile(fs *flag.FlagSet, path string) ([]string, error) {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	decoder := json.NewDecoder(bytes.NewReader(content))
+	// numbers keep their text, e.g. for --workers.
+	decoder.UseNumber()
+	var options map[string]interface{}
+	if err := decoder.Decode(&options); err != nil {
+		return nil, fmt.Errorf("Unable to decode config %s : %w", path, err)
+	}
+
+	given := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		given[f.Name] = true
+	})
+
+	names := make([]string, 0, len(options))
+	for name := range options {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var args []string
+	for _, name := range names {
+		values, ok := options[name].([]interface{})
+		if !ok {
+			values = []interface{}{options[name]}
+		}
+		if name == "args" {
+			for _, value := range values {
+				args = append(args, fmt.Sprint(value))
+			}
+			continue
+		}
+		if fs.Lookup(name) == nil || name == "config" {
+			return nil, fmt.Errorf("Unknown option %q in config %s.", name, path)
+		}
+		if given[name] {
+			continue
+		}
+		for _, value := range values {
+			if err := fs.Set(name, fmt.Sprint(value)); err != nil {
+				return nil, fmt.Errorf("Invalid option %q in config %s: %w", name, path, err)
+			}
+		}
+	}
+	return args, nil
+}
+
+// Validate reports the first invalid option or combination of options.
+func (cfg Config) Validate() error {
+	switch {
+	case cfg.Input == "":
+		return errNoInput
+	case cfg.namespaceGiven && cfg.Namespace == "":
+		return errors.New("Empty Namespace provided.")
+	}
+
+	if cfg.Verbose && cfg.Quiet {
+		return errors.New("--verbose and --quiet cannot be combined.")
+	}
+
+	switch cfg.ConflictStrategy {
+	case "first", "last", "error":
+	default:
+		return fmt.Errorf("Unknown conflict strategy: %s", cfg.ConflictStrategy)
+	}
+	if cfg.ChangelogOut != "" && cfg.Compare == "" {
+		return errors.New("Writing a changelog requires --compare.")
+	}
+	if cfg.Compare != "" {
+		return nil
+	}
+
+	switch cfg.ModuleFormat {
+	case "esm", "cjs", "umd":
+	default:
+		return fmt.Errorf("Unknown module format: %s", cfg.ModuleFormat)
+	}
+	if cfg.ModuleFormat != "esm" && cfg.EmitTsconfig == "" && cfg.EmitPackageJson == "" {
+		return errors.New("A module format only applies to --emit-tsconfig or --emit-package-json.")
+	}
+	if cfg.TsNamespace != "" && (cfg.SplitByTag || cfg.EmitIndex != "" || cfg.EmitCloudRun || cfg.EmitServiceWorkerCache) {
+		return errors.New("A namespace cannot be combined with emitting several files.")
+	}
+	if cfg.ValidateResponses && !cfg.EmitZod {
+		return errors.New("Validating responses requires --emit-zod.")
+	}
+	if cfg.EmitReactHooks && cfg.EmitVueComposables {
+		return errors.New("React hooks and Vue composables cannot be emitted together, as both are named after the operations.")
+	}
+	if cfg.SplitByTag && cfg.GroupByTag {
+		return errors.New("Splitting by tag and grouping by tag cannot be combined.")
+	}
+	if cfg.SplitByTag && len(cfg.OutputDir) < 1 {
+		return errors.New("Splitting by tag requires an output directory.")
+	}
+	if cfg.SplitByTag && cfg.Workers < 1 {
+		return errors.New("Splitting by tag requires at least one worker.")
+	}
+	if cfg.SkipUnchanged && !cfg.SplitByTag && len(cfg.Output) < 1 {
+		return errors.New("Skipping unchanged output requires an output file or --split-by-tag.")
+	}
+	if !cfg.SplitByTag && len(cfg.Output) < 1 && (cfg.EmitCloudRun || cfg.EmitServiceWorkerCache || cfg.EmitIndex != "" || cfg.EmitPackageJson != "" || cfg.EmitTsconfig != "") {
+		return errors.New("Emitting additional files requires an output file.")
+	}
+	if cfg.SplitByTag && cfg.EmitIndex != "" {
+		return errors.New("Splitting by tag already writes an index.ts, so it cannot be combined with --emit-index.")
+	}
+	if cfg.SplitByTag && (cfg.EmitCloudRun || cfg.EmitServiceWorkerCache) {
+		return errors.New("The Cloud Run entrypoint and the service worker are written next to a single output file, so they cannot be combined with --split-by-tag.")
+	}
+	if cfg.EmitIndex != "" && filepath.Clean(cfg.EmitIndex) == filepath.Clean(cfg.Output) {
+		return errors.New("The index cannot be written to the output file.")
+	}
+	return nil
+}
+
+// errNoInput is returned by Config.Validate when no input is given.
+var errNoInput = errors.New("No input file found.")
+
+// errBreakingChanges is returned by generate when --compare finds breaking
+// changes, after they have been reported.
+var errBreakingChanges = errors.New("breaking changes found")
+
+func main() {
+	cfg := NewConfig()
+	if err := cfg.Validate(); err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		if err == errNoInput {
+			flag.PrintDefaults()
+		}
+		os.Exit(1)
+	}
+
+	// Interrupting the generator removes its partial output.
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	if err := generate(ctx, cfg); err != nil {
+		if err == errBreakingChanges {
+			os.Exit(1)
+		}
+		fmt.Fprintln(os.Stderr, err)
+		if cfg.Compare != "" {
+			// 1 is reserved for breaking changes.
+			os.Exit(2)
+		}
+		os.Exit(1)
+	}
+}
+
+// generate reads, validates and renders the specification as configured. It
+// stops early, returning ctx.Err(), when ctx is cancelled.
+func generate(ctx context.Context, cfg Config) error {
+	if err := cfg.Validate(); err != nil {
+		return err
+	}
+	if cfg.Verbose {
+		effective, _ := json.MarshalIndent(cfg, "", "  ")
+		fmt.Fprintf(os.Stderr, "Config: %s\n", effective)
+	}
+
+	content, err := readInput(ctx, cfg.Input, cfg.FetchTimeout, cfg.Insecure)
+	if err != nil {
+		return fmt.Errorf("Unable to read file: %w", err)
+	}
+	if cfg.Verbose {
+		fmt.Fprintf(os.Stderr, "Read %d bytes from %s.\n", len(content), cfg.Input)
+	}
+
+	if cfg.Tsconfig != "" && !cfg.ImportType {
+		isolated, err := isolatedModules(cfg.Tsconfig)
+		if err != nil {
+			return fmt.Errorf("Unable to read %s: %w", cfg.Tsconfig, err)
+		}
+		if isolated && cfg.Verbose {
+			fmt.Fprintf(os.Stderr, "Importing types with \"import type\" for isolatedModules in %s.\n", cfg.Tsconfig)
+		}
+		cfg.ImportType = isolated
+	}
+
+	schema, err := parseSchema(content)
+	if err != nil {
+		return fmt.Errorf("Unable to decode input %s : %w", cfg.Input, err)
+	}
+
+	for _, file := range cfg.Merge {
+		content, err := readInput(ctx, file, cfg.FetchTimeout, cfg.Insecure)
+		if err != nil {
+			return fmt.Errorf("Unable to read file: %w", err)
+		}
+		other, err := parseSchema(content)
+		if err != nil {
+			return fmt.Errorf("Unable to decode input %s : %w", file, err)
+		}
+		if err := mergeSchema(&schema, other, cfg.ConflictStrategy); err != nil {
+			return fmt.Errorf("Unable to merge %s: %w", file, err)
+		}
+	}
+
+	if violations := validateSchema(schema); len(violations) > 0 {
+		return fmt.Errorf("Invalid specification %s:\n  %s", cfg.Input, strings.Join(violations, "\n  "))
+	}
+
+	if cfg.Compare != "" {
+		oldContent, err := readInput(ctx, cfg.Compare, cfg.FetchTimeout, cfg.Insecure)
+		if err != nil {
+			return fmt.Errorf("Unable to read file: %w", err)
+		}
+		old, err := parseSchema(oldContent)
+		if err != nil {
+			return fmt.Errorf("Unable to decode input %s : %w", cfg.Compare, err)
+		}
+
+		breaking, additions := compareSchemas(old, schema)
+		for _, section := range []struct {
+			title   string
+			changes []string
+		}{{"Breaking changes", breaking}, {"Additions", additions}} {
+			if len(section.changes) > 0 {
+				fmt.Printf("%s:\n", section.title)
+				for _, change := range section.changes {
+					fmt.Printf("  %s\n", change)
+				}
+			}
+		}
+		if cfg.ChangelogOut != "" {
+			if err := os.WriteFile(cfg.ChangelogOut, []byte(changelog(old, schema)), 0644); err != nil {
+				return fmt.Errorf("Unable to write file %s: %w", cfg.ChangelogOut, err)
+			}
+		}
+		if len(breaking) > 0 {
+			return errBreakingChanges
+		}
+		return nil
+	}
+
+	if len(cfg.Tags) > 0 {
+		filterByTag(&schema, cfg.Tags)
+	}
+	if len(cfg.PathPrefixes) > 0 {
+		filterByPathPrefix(&schema, cfg.PathPrefixes)
+	}
+
+	if cfg.OmitDeprecated {
+		removeDeprecated(&schema)
+	}
+	if cfg.PruneUnused {
+		pruned := pruneDefinitions(&schema)
+		if cfg.Verbose {
+			fmt.Fprintf(os.Stderr, "Pruned %d unused definitions.\n", pruned)
+		}
+	}
+	if _, ok := healthOperation(schema.Paths); cfg.EmitHealthCheck && !ok {
+		return errors.New("Emitting a health checker requires a /healthcheck path or an operation with the x-nakama-health extension, without required parameters.")
+	}
+
+	schema.Namespace = cfg.Namespace
+	schema.Prefix = cfg.Prefix
+	schema.TsNamespace = cfg.TsNamespace
+	schema.Bigint = !cfg.NoBigint
+	schema.DateReviver = cfg.DateReviver
+	schema.Indent = cfg.Indent
+	schema.Strict = cfg.Strict
+	schema.EmitZod = cfg.EmitZod
+	schema.EmitIoTs = cfg.EmitIoTs
+	schema.EmitMock = cfg.EmitMock
+	schema.EmitDefaults = cfg.EmitDefaults
+	schema.EmitOtel = cfg.EmitOtel
+	schema.EmitReactHooks = cfg.EmitReactHooks
+	schema.EmitVueComposables = cfg.EmitVueComposables
+	schema.EmitRxjs = cfg.EmitRxjs
+	schema.EmitAngular = cfg.EmitAngular
+	schema.ValidateResponses = cfg.ValidateResponses
+	schema.EmitTypeGuards = cfg.EmitTypeGuards
+	schema.NoConstEnum = cfg.NoConstEnum
+	schema.Quiet = cfg.Quiet
+	schema.NoBanner = cfg.NoBanner
+	schema.ImportType = cfg.ImportType
+	schema.EmitRequestTypes = cfg.EmitRequestTypes
+	schema.EmitResponseTypes = cfg.EmitResponseTypes
+	schema.GroupByTag = cfg.GroupByTag
+	if cfg.GroupByTag {
+		if err := checkTagProperties(schema.Paths); err != nil {
+			return err
+		}
+	}
+	if _, err := integerEnums(schema.Definitions); err != nil {
+		return err
+	}
+	schema.EmitDedup = cfg.EmitDedup
+	schema.EmitOfflineQueue = cfg.EmitOfflineQueue
+	schema.EmitHealthCheck = cfg.EmitHealthCheck
+	// a package cannot rely on a utils module next to the output.
+	schema.InlineUtils = cfg.EmitPackageJson != ""
+	if schema.Indent == "tab" {
+		schema.Indent = "\t"
+	}
+
+	fmap := funcMap(&schema)
+
+	if cfg.EmitPostman != "" {
+		if err := renderFile(ctx, cfg.EmitPostman, postmanTemplate, fmap, schema); err != nil {
+			return fmt.Errorf("Unable to write Postman collection: %w", err)
+		}
+	}
+	if cfg.EmitHttp != "" {
+		if err := renderFile(ctx, cfg.EmitHttp, httpTemplate, fmap, schema); err != nil {
+			return fmt.Errorf("Unable to write HTTP Client file: %w", err)
+		}
+	}
+	if cfg.EmitBruno != "" {
+		if err := writeBruno(ctx, cfg.EmitBruno, fmap, schema); err != nil {
+			return fmt.Errorf("Unable to write Bruno collection: %w", err)
+		}
+	}
+	if cfg.EmitDocs != "" {
+		if err := renderFile(ctx, cfg.EmitDocs, docsTemplate, fmap, schema); err != nil {
+			return fmt.Errorf("Unable to write Markdown reference: %w", err)
+		}
+	}
+
+	if cfg.Verbose {
+		fmt.Fprintf(os.Stderr, "Rendering %d definitions and %d operations.\n", len(schema.Definitions), len(operations(schema.Paths)))
+	}
+	start := time.Now()
+	logRendered := func(output string) {
+		if cfg.Verbose {
+			fmt.Fprintf(os.Stderr, "Rendered %s in %s.\n", output, time.Since(start).Round(time.Millisecond))
+		}
+	}
+
+	tmpl, err := template.New(cfg.Input).Funcs(fmap).Parse(indentTemplate(codeTemplate))
+	if err != nil {
+		return fmt.Errorf("Template parse error: %w", err)
+	}
+
+	if cfg.SplitByTag {
+		var hashes *splitHashes
+		if cfg.SkipUnchanged {
+			hashes = newSplitHashes(cfg.OutputDir, cfg)
+		}
+		if err := writeSplitByTag(ctx, cfg.OutputDir, tmpl, schema, cfg.Workers, hashes); err != nil {
+			return fmt.Errorf("Unable to write split output: %w", err)
+		}
+		if err := hashes.save(); err != nil {
+			return fmt.Errorf("Unable to write %s: %w", filepath.Join(cfg.OutputDir, hashFile), err)
+		}
+		logRendered(cfg.OutputDir)
+		if cfg.EmitPackageJson != "" {
+			if err := writePackageJson(cfg.EmitPackageJson, cfg, schema); err != nil {
+				return fmt.Errorf("Unable to write package.json: %w", err)
+			}
+		}
+		if cfg.EmitTsconfig != "" {
+			if err := writeTsconfig(cfg.EmitTsconfig, cfg); err != nil {
+				return fmt.Errorf("Unable to write tsconfig.json: %w", err)
+			}
+		}
+		return nil
+	}
+
+	if len(cfg.Output) < 1 {
+		if err := tmpl.Execute(contextWriter{ctx, os.Stdout}, schema); err != nil {
+			return fmt.Errorf("Unable to generate code: %w", err)
+		}
+		logRendered("stdout")
+		return nil
+	}
+
+	var rendered []byte
+	var hash string
+	hashPath := filepath.Join(filepath.Dir(cfg.Output), hashFile)
+	if cfg.SkipUnchanged {
+		var buf bytes.Buffer
+		if err := tmpl.Execute(contextWriter{ctx, &buf}, schema); err != nil {
+			return fmt.Errorf("Unable to generate code: %w", err)
+		}
+		rendered = buf.Bytes()
+		if hash, err = outputHash(cfg, rendered); err != nil {
+			return fmt.Errorf("Unable to hash output: %w", err)
+		}
+		if _, err := os.Stat(cfg.Output); err == nil && readHashes(hashPath)[filepath.Base(cfg.Output)] == hash && exist(sideFiles(cfg)) {
+			if !cfg.Quiet {
+				fmt.Printf("%s unchanged, skipping\n", cfg.Output)
+			}
+			return nil
+		}
+	}
+
+	f, err := os.Create(cfg.Output)
+	if err != nil {
+		return fmt.Errorf("Unable to create file %w", err)
+	}
+	defer f.Close()
+
+	writer := bufio.NewWriter(contextWriter{ctx, f})
+	if rendered != nil {
+		_, err = writer.Write(rendered)
+	} else {
+		err = tmpl.Execute(writer, schema)
+	}
+	if err != nil {
+		removePartialOutput(f)
+		return fmt.Errorf("Unable to generate code: %w", err)
+	}
+	if err := writer.Flush(); err != nil {
+		removePartialOutput(f)
+		return fmt.Errorf("Unable to write file %s: %w", cfg.Output, err)
+	}
+	logRendered(cfg.Output)
+
+	schema.ClientModule = "./" + strings.TrimSuffix(filepath.Base(cfg.Output), ".ts")
+
+	if cfg.EmitCloudRun {
+		path := filepath.Join(filepath.Dir(cfg.Output), "main.ts")
+		if err := renderFile(ctx, path, cloudRunTemplate, fmap, schema); err != nil {
+			return fmt.Errorf("Unable to write Cloud Run entrypoint: %w", err)
+		}
+	}
+
+	if cfg.EmitServiceWorkerCache {
+		path := filepath.Join(filepath.Dir(cfg.Output), "nakama-sw.ts")
+		if err := renderFile(ctx, path, serviceWorkerTemplate, fmap, schema); err != nil {
+			return fmt.Errorf("Unable to write service worker: %w", err)
+		}
+	}
+
+	if cfg.EmitIndex != "" {
+		// the index imports the client relative to its own directory.
+		index := schema
+		module, err := filepath.Rel(filepath.Dir(cfg.EmitIndex), strings.TrimSuffix(cfg.Output, ".ts"))
+		if err != nil {
+			return fmt.Errorf("Unable to write index: %w", err)
+		}
+		index.ClientModule = filepath.ToSlash(module)
+		if !strings.HasPrefix(index.ClientModule, "../") {
+			index.ClientModule = "./" + index.ClientModule
+		}
+		if err := renderFile(ctx, cfg.EmitIndex, indexTemplate, fmap, index); err != nil {
+			return fmt.Errorf("Unable to write index: %w", err)
+		}
+	}
+
+	if cfg.EmitPackageJson != "" {
+		if err := writePackageJson(cfg.EmitPackageJson, cfg, schema); err != nil {
+			return fmt.Errorf("Unable to write package.json: %w", err)
+		}
+	}
+
+	if cfg.EmitTsconfig != "" {
+		if err := writeTsconfig(cfg.EmitTsconfig, cfg); err != nil {
+			return fmt.Errorf("Unable to write tsconfig.json: %w", err)
+		}
+	}
+
+	if cfg.SkipUnchanged {
+		if err := writeHash(hashPath, filepath.Base(cfg.Output), hash); err != nil {
+			return fmt.Errorf("Unable to write %s: %w", hashPath, err)
+		}
+	}
+	return nil
+}
+
+// hashFile stores the hashes of the outputs in their directory for
+// --skip-unchanged, one "<hash>  <file name>" line per output like sha256sum.
+const hashFile = ".nakama-gen-hash"
+
+// outputHash returns the hex SHA-256 hash of the options and the generated
+// code, so that changing an option which only affects the additional files
+// written next to the output also regenerates them. Options for reading the
+// input and logging are left out, as they do not change what is written.
+func outputHash(cfg Config, code []byte) (string, error) {
+	cfg.Input, cfg.FetchTimeout, cfg.Insecure = "", 0, false
+	cfg.Verbose, cfg.Quiet, cfg.Workers, cfg.SkipUnchanged = false, false, 0, false
+	options, err := json.Marshal(cfg)
+	if err != nil {
+		return "", err
+	}
+	hash := sha256.New()
+	hash.Write(options)
+	hash.Write(code)
+	return hex.EncodeToString(hash.Sum(nil)), nil
+}
+
+// readHashes reads the hashes of a hash file by file name. A missing or
+// malformed file has no hashes.
+func readHashes(path string) map[string]string {
+	hashes := make(map[string]string)
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return hashes
+	}
+	for _, line := range strings.Split(string(content), "\n") {
+		if hash, name, ok := strings.Cut(line, "  "); ok {
+			hashes[name] = hash
+		}
+	}
+	return hashes
+}
+
+// writeHash stores the hash of the output with the given file name in a hash
+// file, keeping the hashes of other outputs.
+func writeHash(path string, name string, hash string) error {
+	hashes := readHashes(path)
+	hashes[name] = hash
+	return writeHashes(path, hashes)
+}
+
+// writeHashes writes a hash file with the given hashes by file name.
+func writeHashes(path string, hashes map[string]string) error {
+	names := make([]string, 0, len(hashes))
+	for name := range hashes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var content strings.Builder
+	for _, name := range names {
+		fmt.Fprintf(&content, "%s  %s\n", hashes[name], name)
+	}
+	return os.WriteFile(path, []byte(content.String()), 0644)
+}
+
+// sideFiles returns the files written next to a single output file, which
+// are regenerated when missing even though the output is unchanged.
+func sideFiles(cfg Config) []string {
+	var paths []string
+	if cfg.EmitCloudRun {
+		paths = append(paths, filepath.Join(filepath.Dir(cfg.Output), "main.ts"))
+	}
+	if cfg.EmitServiceWorkerCache {
+		paths = append(paths, filepath.Join(filepath.Dir(cfg.Output), "nakama-sw.ts"))
+	}
+	for _, path := range []string{cfg.EmitIndex, cfg.EmitPackageJson, cfg.EmitTsconfig} {
+		if path != "" {
+			paths = append(paths, path)
+		}
+	}
+	return paths
+}
+
+// exist reports whether all the files exist.
+func exist(paths []string) bool {
+	for _, path := range paths {
+		if _, err := os.Stat(path); err != nil {
+			return false
+		}
+	}
+	return true
+}
+
+// splitHashes skips writing the --split-by-tag files which exist and whose
+// hash is unchanged since the last run, storing the hashes in the hash file
+// of their directory. A nil *splitHashes writes every file.
+type splitHashes struct {
+	path   string
+	cfg    Config
+	old    map[string]string
+	mu     sync.Mutex
+	hashes map[string]string
+}
+
+// newSplitHashes returns the hashes of the files of a split output directory.
+func newSplitHashes(dir string, cfg Config) *splitHashes {
+	path := filepath.Join(dir, hashFile)
+	return &splitHashes{path: path, cfg: cfg, old: readHashes(path), hashes: make(map[string]string)}
+}
+
+// write writes a file unless it is unchanged. It is safe for concurrent use.
+func (s *splitHashes) write(path string, content []byte) error {
+	if s == nil {
+		return os.WriteFile(path, content, 0644)
+	}
+
+	hash, err := outputHash(s.cfg, content)
+	if err != nil {
+		return err
+	}
+	name := filepath.Base(path)
+	s.mu.Lock()
+	s.hashes[name] = hash
+	s.mu.Unlock()
+
+	if _, err := os.Stat(path); err == nil && s.old[name] == hash {
+		if !s.cfg.Quiet {
+			fmt.Printf("%s unchanged, skipping\n", path)
+		}
+		return nil
+	}
+	return os.WriteFile(path, content, 0644)
+}
+
+// save writes the hashes of the files of this run, dropping those of files
+// which are no longer generated.
+func (s *splitHashes) save() error {
+	if s == nil {
+		return nil
+	}
+	return writeHashes(s.path, s.hashes)
+}
+
+// contextWriter fails writes once its context is cancelled, which stops the
+// execution of a template writing to it.
+type contextWriter struct {
+	ctx context.Context
+	w   io.Writer
+}
+
+func (w contextWriter) Write(p []byte) (int, error) {
+	if err := w.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return w.w.Write(p)
+}
+
+// stdin is read for the input "-". Tests replace it.
+var stdin io.Reader = os.Stdin
+
+// readInput reads the specification from a file or from stdin for "-", or
+// fetches it when the input is an http:// or https:// URL.
+func readInput(ctx context.Context, input string, timeout time.Duration, insecure bool) ([]byte, error) {
+	if input == "-" {
+		return io.ReadAll(stdin)
+	}
+	if !strings.HasPrefix(input, "http://") && !strings.HasPrefix(input, "https://") {
+		return os.ReadFile(input)
+	}
+
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			Proxy:           http.ProxyFromEnvironment,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: insecure},
+		},
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, input, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching %s: %s", input, resp.Status)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+// parseSchema decodes a Swagger specification.
+func parseSchema(content []byte) (Schema, error) {
+	var schema Schema
+	if err := json.Unmarshal(content, &schema); err != nil {
+		return schema, err
+	}
+
+	for _, definitions := range []map[string]Definition{schema.Defs, schema.Components.Schemas} {
+		if len(definitions) > 0 {
+			if err := mergeSchema(&schema, Schema{Definitions: definitions}, "error"); err != nil {
+				return schema, err
+			}
+		}
+	}
+	schema.Defs = nil
+	schema.Components.Schemas = nil
+
+	for _, path := range schema.Paths {
+		for method, operation := range path {
+			if len(operation.Consumes) == 0 {
+				operation.Consumes = schema.Consumes
+			}
+			if len(operation.Produces) == 0 {
+				operation.Produces = schema.Produces
+			}
+			for i, parameter := range operation.Parameters {
+				operation.Parameters[i].File = parameter.In == "formData" && parameter.Type == "file"
+			}
+			if body, ok := operation.bodyParameter(); ok {
+				operation.Parameters = append(operation.Parameters, body)
+			}
+			if consumesMultipart(operation) {
+				operation.Parameters = multipartParameters(operation.Parameters)
+			}
+			path[method] = operation
+		}
+	}
+	return schema, nil
+}
+
+var pathParameter = regexp.MustCompile(`\{([^}]+)\}`)
+
+// mergeSchema adds the paths and definitions of another specification to a
+// schema. Those defined differently in both are resolved with the strategy
+// "first", "last" or "error", comparing their JSON forms.
+func mergeSchema(schema *Schema, other Schema, strategy string) error {
+	// replace reports whether the incoming value wins over the existing one.
+	replace := func(kind, name string, existing, incoming interface{}) (bool, error) {
+		a, err := json.MarshalIndent(existing, "", "  ")
+		if err != nil {
+			return false, err
+		}
+		b, err := json.MarshalIndent(incoming, "", "  ")
+		if err != nil {
+			return false, err
+		}
+		switch {
+		case bytes.Equal(a, b) || strategy == "first":
+			return false, nil
+		case strategy == "last":
+			return true, nil
+		default:
+			return false, fmt.Errorf("%s %s is defined differently:\n%s\n%s", kind, name, a, b)
+		}
+	}
+
+	if schema.Paths == nil {
+		schema.Paths = make(map[string]map[string]Operation)
+	}
+	for url, path := range other.Paths {
+		if schema.Paths[url] == nil {
+			schema.Paths[url] = make(map[string]Operation)
+		}
+		for method, operation := range path {
+			if existing, ok := schema.Paths[url][method]; ok {
+				wins, err := replace("operation", strings.ToUpper(method)+" "+url, existing, operation)
+				if err != nil {
+					return err
+				}
+				if !wins {
+					continue
+				}
+			}
+			schema.Paths[url][method] = operation
+		}
+	}
+
+	if schema.Definitions == nil {
+		schema.Definitions = make(map[string]Definition)
+	}
+	for name, definition := range other.Definitions {
+		if existing, ok := schema.Definitions[name]; ok {
+			wins, err := replace("definition", name, existing, definition)
+			if err != nil {
+				return err
+			}
+			if !wins {
+				continue
+			}
+		}
+		schema.Definitions[name] = definition
+	}
+	return nil
+}
+
+// validateSchema returns the mistakes in a specification which would
+// otherwise produce silently wrong code, sorted for stable output.
+func validateSchema(schema Schema) []string {
+	var violations []string
+	checkRef := func(ref, where string) {
+		if ref == "" {
+			return
+		}
+		if _, ok := schema.Definitions[refName(ref)]; !ok {
+			violations = append(violations, fmt.Sprintf("%s refers to undefined definition %s", where, ref))
+		}
+	}
+
+	for url, path := range schema.Paths {
+		for method, operation := range path {
+			where := strings.ToUpper(method) + " " + url
+			if operation.OperationId == "" {
+				violations = append(violations, where+" has no operationId")
+			}
+			checkRef(operation.Responses.Ok.Schema.Ref, where+" response")
+
+			declared := make(map[string]bool)
+			for _, parameter := range operation.Parameters {
+				if parameter.In == "path" {
+					declared[parameter.Name] = true
+				}
+				if parameter.In == "body" && !parameter.Multipart && parameter.Schema.Type == "" && parameter.Schema.Ref == "" {
+					violations = append(violations, fmt.Sprintf("%s body parameter %s has no schema", where, parameter.Name))
+				}
+				checkRef(parameter.Schema.Ref, fmt.Sprintf("%s parameter %s", where, parameter.Name))
+			}
+			for _, match := range pathParameter.FindAllStringSubmatch(url, -1) {
+				if !declared[match[1]] {
+					violations = append(violations, fmt.Sprintf("%s does not declare path parameter %s", where, match[1]))
+				}
+			}
+		}
+	}
+	for operationId, paths := range operationPaths(schema) {
+		if len(paths) > 1 {
+			violations = append(violations, fmt.Sprintf("operationId %s is used by %s", operationId, strings.Join(paths, ", ")))
+		}
+	}
+
+	for name, definition := range schema.Definitions {
+		for key, property := range definition.Properties {
+			checkRef(property.Ref, fmt.Sprintf("property %s of %s", key, name))
+			checkRef(property.Items.Ref, fmt.Sprintf("property %s of %s", key, name))
+		}
+	}
+
+	sort.Strings(violations)
+	return violations
+}
+
+// operationPaths maps each operationId to the sorted operations using it,
+// such as "GET /v2/account". Only duplicate operationIds have several.
+func operationPaths(schema Schema) map[string][]string {
+	paths := make(map[string][]string)
+	for url, path := range schema.Paths {
+		for method, operation := range path {
+			if operation.OperationId != "" {
+				paths[operation.OperationId] = append(paths[operation.OperationId], strings.ToUpper(method)+" "+url)
+			}
+		}
+	}
+	for _, operations := range paths {
+		sort.Strings(operations)
+	}
+	return paths
+}
+
+// funcMap returns the functions available to the templates. Some of them
+// depend on the definitions and options of the schema being rendered.
+func funcMap(schema *Schema) template.FuncMap {
+	// the conversions and recursive definitions are looked up for every
+	// operation or definition, so they are only derived once, when the
+	// definitions are final.
+	var (
+		conversionsOnce sync.Once
+		conversions     map[string]map[string]string
+		recursiveOnce   sync.Once
+		recursive       map[string]bool
+	)
+	jsonConversionsOnce := func() map[string]map[string]string {
+		conversionsOnce.Do(func() {
+			conversions = jsonConversions(schema.Definitions, schema.DateReviver, schema.Bigint)
+		})
+		return conversions
+	}
+
+	return template.FuncMap{
+		"enumDescriptions": enumDescriptions,
+		"enumSummary":      enumSummary,
+		"snakeToCamel":     snakeToCamel,
+		"escapeReserved":   escapeReserved,
+		"camelToPascal":    camelToPascal,
+		"cleanRef": func(ref string) string {
+			return schema.Prefix + convertRefToClassName(ref)
+		},
+		"integerEnumName": func(key string, property Property) string {
+			if name := integerEnumName(key, property); name != "" {
+				return schema.Prefix + name
+			}
+			return ""
+		},
+		"integerEnums": func(definitions map[string]Definition) []IntegerEnum {
+			// generate already rejected conflicting enums.
+			enums, _ := integerEnums(definitions)
+			for i := range enums {
+				enums[i].Name = schema.Prefix + enums[i].Name
+			}
+			return enums
+		},
+		"isRefToEnum": func(ref string) bool {
+			// swagger schema definition keys have inconsistent casing
+			var camelOk bool
+			var pascalOk bool
+			var enums []string
+
+			asCamel := pascalToCamel(ref)
+			if _, camelOk = schema.Definitions[asCamel]; camelOk {
+				enums = schema.Definitions[asCamel].Enum
+			}
+
+			asPascal := camelToPascal(ref)
+			if _, pascalOk = schema.Definitions[asPascal]; pascalOk {
+				enums = schema.Definitions[asPascal].Enum
+			}
+
+			if !pascalOk && !camelOk {
+				if !schema.Quiet {
+					fmt.Fprintf(os.Stderr, "no definition found: %v\n", ref)
+				}
+				return false
+			}
+
+			return len(enums) > 0
+		},
+		"title":                title,
+		"camelToSnake":         camelToSnake,
+		"snakeCase":            snakeCase,
+		"uppercase":            strings.ToUpper,
+		"lowercase":            strings.ToLower,
+		"pathPattern":          pathPattern,
+		"stripOperationPrefix": stripOperationPrefix,
+		"replace":              replace,
+		"repeat":               strings.Repeat,
+		"isIdempotent":         isIdempotent,
+		"responseType":         responseType,
+		"uploadsFile":          uploadsFile,
+		"requiresRequest":      requiresRequest,
+		"isRequired":           isRequired,
+		"guardCheck":           guardCheck,
+		"operations":           operations,
+		"queryParameters":      queryParameters,
+		"formParameters":       formParameters,
+		"tagGroups":            tagGroups,
+		"tagProperty":          tagProperty,
+		"credentials":          credentials,
+		"offlineQueued":        offlineQueued,
+		"methodName":           methodName,
+		"healthOperation": func(paths map[string]map[string]Operation) *OperationRef {
+			if ref, ok := healthOperation(paths); ok {
+				return &ref
+			}
+			return nil
+		},
+		"requiredParameters": requiredParameters,
+		"placeholder":        placeholder,
+		"variableQuery":      variableQuery,
+		"operationVariables": operationVariables,
+		"markdownCell":       markdownCell,
+		"code":               markdownCode,
+		"markdownType": func(property Property) string {
+			switch {
+			case property.Ref != "":
+				return markdownLink(schema.Prefix + convertRefToClassName(property.Ref))
+			case property.Type == "array" && property.Items.Ref != "":
+				return "array of " + markdownLink(schema.Prefix+convertRefToClassName(property.Items.Ref))
+			default:
+				return markdownCell(propertyType(property))
+			}
+		},
+		"markdownReturnType": func(operation Operation) string {
+			if operation.Responses.Ok.Schema.Ref != "" && responseType(operation.Produces) == "json" {
+				return markdownLink(returnType(operation, schema.Prefix))
+			}
+			return markdownCode(returnType(operation, schema.Prefix))
+		},
+		"variableUrl":              variableUrl,
+		"variablePath":             variablePath,
+		"pathVariables":            pathVariables,
+		"authorizationPlaceholder": authorizationPlaceholder,
+		"json": func(value interface{}) (string, error) {
+			data, err := json.Marshal(value)
+			return string(data), err
+		},
+		"requestExample": func(operation Operation) (string, error) {
+			for _, parameter := range operation.Parameters {
+				if parameter.In != "body" || parameter.Multipart {
+					continue
+				}
+				var value interface{} = ""
+				if parameter.Schema.Ref != "" {
+					value = exampleDefinition(refName(parameter.Schema.Ref), schema.Definitions, 3)
+				}
+				data, err := json.MarshalIndent(value, "", "  ")
+				return string(data), err
+			}
+			return "", nil
+		},
+		"constraintTags": constraintTags,
+		"headerField":    headerField,
+		"defaultValue": func(property Property) string {
+			return defaultValue(property, schema.Bigint, schema.DateReviver)
+		},
+		"parameterType": func(parameter Parameter) string {
+			return parameterType(parameter, schema.Prefix, schema.Bigint)
+		},
+		"zodType": func(property Property) string {
+			return zodType(property, schema.Prefix, schema.Bigint, schema.DateReviver)
+		},
+		"ioTsCodec": func(definition Definition) string {
+			return ioTsCodec(definition, schema.Prefix, schema.Indent, schema.DateReviver, schema.Bigint)
+		},
+		"headerType": func(header HeaderDefinition) string {
+			return headerType(header, schema.Bigint)
+		},
+		"headerValue": func(header HeaderDefinition, value string) string {
+			return headerValue(header, value, schema.Bigint)
+		},
+		"returnType": func(operation Operation) string {
+			return returnType(operation, schema.Prefix)
+		},
+		"resultType": func(operation Operation) string {
+			if schema.EmitResponseTypes && !operation.XNakamaSse {
+				return schema.Prefix + camelToPascal(snakeToCamel(stripOperationPrefix(operation.Name()))) + "Response"
+			}
+			return returnType(operation, schema.Prefix)
+		},
+		"operationParameters": func(operation Operation) []string {
+			return operationParameters(operation, schema.Prefix, schema.Bigint, schema.EmitRequestTypes)
+		},
+		"operationArguments": func(operation Operation) []string {
+			return operationArguments(operation, schema.EmitRequestTypes)
+		},
+		"requestParameters": func(operation Operation) []Parameter {
+			if !schema.EmitRequestTypes {
+				return nil
+			}
+			return requestParameters(operation)
+		},
+		"jsonConversions": jsonConversionsOnce,
+		"hasBytes": func() bool {
+			return hasBytes(schema.Definitions)
+		},
+		"bigints": func() bool {
+			return schema.Bigint && hasInt64(schema.Definitions)
+		},
+		"convertsTo": func(operation Operation) string {
+			name := refName(operation.Responses.Ok.Schema.Ref)
+			if _, ok := jsonConversionsOnce()[name]; ok {
+				return name
+			}
+			return ""
+		},
+		"ioTsOrder": ioTsOrder,
+		"ioTsRecursive": func(name string) bool {
+			recursiveOnce.Do(func() {
+				recursive = ioTsRecursive(schema.Definitions)
+			})
+			return recursive[name]
+		},
+	}
+}
+
+// removePartialOutput closes and deletes an output file which could not be
+// written completely, so partial output is not mistaken for a valid client.
+// Devices and other special files are only closed.
+func removePartialOutput(f *os.File) {
+	info, err := f.Stat()
+	f.Close()
+	if err == nil && info.Mode().IsRegular() {
+		os.Remove(f.Name())
+	}
+}
+
+// indentTemplate replaces the two-space indentation of the template lines
+// which produce output with calls to repeat, so the generated code is indented
+// by the schema's Indent instead. Whitespace which the template trims anyway
+// is left alone.
+func indentTemplate(text string) string {
+	lines := strings.Split(text, "\n")
+	trimmed := false
+	for i, line := range lines {
+		body := strings.TrimLeft(line, " ")
+		width := len(line) - len(body)
+		if !trimmed && width >= 2 && !strings.HasPrefix(body, "{{-") {
+			lines[i] = fmt.Sprintf("{{ repeat $.Indent %d }}%s%s", width/2, strings.Repeat(" ", width%2), body)
+		}
+		trimmed = strings.HasSuffix(line, "-}}")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// renderFile executes a template with the given data and writes the result to path.
+func renderFile(ctx context.Context, path string, text string, fmap template.FuncMap, data interface{}) error {
+	tmpl, err := template.New(filepath.Base(path)).Funcs(fmap).Parse(indentTemplate(text))
+	if err != nil {
+		return err
+	}
+	return executeFile(ctx, path, tmpl, data)
+}
+
+// executeFile executes a parsed template with the given data and writes the result to path.
+func executeFile(ctx context.Context, path string, tmpl *template.Template, data interface{}) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	writer := bufio.NewWriter(contextWriter{ctx, f})
+	if err := tmpl.Execute(writer, data); err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+// groupByTag splits API paths by the first tag of each operation. Operations
+// without a tag are grouped under "default".
+func groupByTag(paths map[string]map[string]Operation) map[string]map[string]map[string]Operation {
+	groups := make(map[string]map[string]map[string]Operation)
+	for url, path := range paths {
+		for method, operation := range path {
+			tag := "default"
+			if len(operation.Tags) > 0 {
+				tag = operation.Tags[0]
+			}
+			if groups[tag] == nil {
+				groups[tag] = make(map[string]map[string]Operation)
+			}
+			if groups[tag][url] == nil {
+				groups[tag][url] = make(map[string]Operation)
+			}
+			groups[tag][url][method] = operation
+		}
+	}
+	return groups
+}
+
+// tagFileName converts a tag into a file name without extension.
+func tagFileName(tag string) string {
+	return snakeCase(nonIdentifier.ReplaceAllString(tag, "_"))
+}
+
+// tagProperty converts a tag into the name of its --group-by-tag object, e.g.
+// "LeaderboardRecords" becomes "leaderboardRecords".
+func tagProperty(tag string) string {
+	return snakeToCamel(tagFileName(tag))
+}
+
+// writeSplitByTag writes the type definitions, one API class per tag and a
+// barrel index.ts re-exporting all of them into dir. The API classes are
+// rendered by a pool of workers; the errors of all of them are returned.
+// Unchanged files are skipped with hashes.
+func writeSplitByTag(ctx context.Context, dir string, tmpl *template.Template, schema Schema, workers int, hashes *splitHashes) error {
+	groups := groupByTag(schema.Paths)
+	tags := make([]string, 0, len(groups))
+	for tag := range groups {
+		tags = append(tags, tag)
+	}
+	sort.Strings(tags)
+
+	// tags such as "Leaderboard Records" and "leaderboard_records" share a
+	// file, and no tag may overwrite definitions.ts or index.ts.
+	files := map[string]string{"definitions": "the type definitions", "index": "the index"}
+	for _, tag := range tags {
+		name := tagFileName(tag)
+		if other, ok := files[name]; ok {
+			return fmt.Errorf("Tag %q would overwrite %s in %s.ts.", tag, other, name)
+		}
+		files[name] = fmt.Sprintf("tag %q", tag)
+	}
+
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+
+	// the output directory has no utils module next to it.
+	schema.InlineUtils = true
+
+	// The API classes import what they use of the symbols which the first
+	// pass exports from definitions.ts.
+	definitions := schema
+	definitions.DefinitionsOnly = true
+	var buf bytes.Buffer
+	if err := tmpl.Execute(contextWriter{ctx, &buf}, definitions); err != nil {
+		return err
+	}
+	if err := hashes.write(filepath.Join(dir, "definitions.ts"), buf.Bytes()); err != nil {
+		return err
+	}
+	symbols := exportedSymbols(buf.String())
+
+	jobs := make(chan string)
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for tag := range jobs {
+				name := tagFileName(tag)
+
+				api := schema
+				api.ApiOnly = true
+				api.ApiSuffix = camelToPascal(snakeToCamel(name))
+				api.Paths = groups[tag]
+				if err := writeWithImports(ctx, filepath.Join(dir, name+".ts"), tmpl, api, symbols, schema.ImportType, hashes); err != nil {
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("%s.ts: %w", name, err))
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+	for _, tag := range tags {
+		jobs <- tag
+	}
+	close(jobs)
+	wg.Wait()
+
+	if len(errs) > 0 {
+		sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+		return errors.Join(errs...)
+	}
+
+	index := "export * from \"./definitions\";\n"
+	if !schema.NoBanner {
+		index = "/* Code generated by openapi-gen/main.go. DO NOT EDIT. */\n\n" + index
+	}
+	for _, tag := range tags {
+		index += fmt.Sprintf("export * from \"./%s\";\n", tagFileName(tag))
+	}
+
+	return hashes.write(filepath.Join(dir, "index.ts"), []byte(index))
 }
 
-func convertRefToClassName(input string) (className string) {
-	cleanRef := strings.TrimPrefix(input, "#/definitions/")
-	className = strings.Title(cleanRef)
-	return
+// BrunoRequest is the data of brunoTemplate.
+type BrunoRequest struct {
+	OperationRef
+	Seq    int
+	Indent string
 }
 
-func iscamelToSnake(input string) (output bool) {
+// writeBruno writes a Bruno collection into dir: a bruno.json file, a local
+// environment declaring baseUrl and the credentials, and one .bru file per
+// operation in a subdirectory per tag.
+func writeBruno(ctx context.Context, dir string, fmap template.FuncMap, schema Schema) error {
+	tmpl, err := template.New("bru").Funcs(fmap).Parse(indentTemplate(brunoTemplate))
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
 
-	output = true
+	collection, err := json.MarshalIndent(map[string]interface{}{
+		"version": "1",
+		"name":    schema.Prefix + schema.Namespace,
+		"type":    "collection",
+	}, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(filepath.Join(dir, "bruno.json"), append(collection, '\n'), 0644); err != nil {
+		return err
+	}
 
-	for _, v := range input {
-		vString := string(v)
-		if vString != "_" && strings.ToUpper(vString) == vString {
-			output = false
+	if err := os.MkdirAll(filepath.Join(dir, "environments"), 0755); err != nil {
+		return err
+	}
+	environment := fmt.Sprintf("vars {\n%[1]sbaseUrl: http://127.0.0.1:7350\n%[1]sbearerToken:\n%[1]sbasicAuth:\n}\n", schema.Indent)
+	if err := os.WriteFile(filepath.Join(dir, "environments", "local.bru"), []byte(environment), 0644); err != nil {
+		return err
+	}
+
+	for _, group := range tagGroups(schema.Paths) {
+		folder := filepath.Join(dir, tagFileName(group.Tag))
+		if err := os.MkdirAll(folder, 0755); err != nil {
+			return err
+		}
+		for i, operation := range group.Operations {
+			request := BrunoRequest{OperationRef: operation, Seq: i + 1, Indent: schema.Indent}
+			path := filepath.Join(folder, stripOperationPrefix(operation.Name())+".bru")
+			if err := executeFile(ctx, path, tmpl, request); err != nil {
+				return err
+			}
 		}
 	}
+	return nil
+}
 
-	return
+// PackageJson is the package.json of the generated client, compiled into dist.
+type PackageJson struct {
+	Name             string                   `json:"name"`
+	Version          string                   `json:"version"`
+	Description      string                   `json:"description"`
+	Type             string                   `json:"type"`
+	Main             string                   `json:"main"`
+	Types            string                   `json:"types"`
+	Exports          map[string]PackageExport `json:"exports"`
+	Files            []string                 `json:"files"`
+	Scripts          map[string]string        `json:"scripts"`
+	Dependencies     map[string]string        `json:"dependencies"`
+	PeerDependencies map[string]string        `json:"peerDependencies,omitempty"`
+	DevDependencies  map[string]string        `json:"devDependencies"`
 }
 
-// camelToPascal converts a string from camel case to Pascal case.
-func camelToPascal(camelCase string) (pascalCase string) {
+// PackageExport is an entry point of a package.json "exports" field. TypeScript
+// requires the "types" condition to come first.
+type PackageExport struct {
+	Types   string `json:"types"`
+	Import  string `json:"import,omitempty"`
+	Require string `json:"require,omitempty"`
+}
 
-	if len(camelCase) <= 0 {
-		return ""
+// packageVersion completes a version such as "2.0" from the info of the
+// spec to the "2.0.0" semantic version npm requires.
+func packageVersion(version string) string {
+	switch strings.Count(version, ".") {
+	case 0:
+		if version == "" {
+			return "0.0.0"
+		}
+		return version + ".0.0"
+	case 1:
+		return version + ".0"
+	default:
+		return version
 	}
+}
 
-	pascalCase = strings.ToUpper(string(camelCase[0])) + camelCase[1:]
-	return
+// clientModule returns the path of the module exporting the generated client,
+// which is the index.ts barrel when splitting by tag.
+func clientModule(cfg Config) string {
+	if cfg.SplitByTag {
+		return filepath.Join(cfg.OutputDir, "index.ts")
+	}
+	return cfg.Output
 }
 
-func camelToSnake(input string) (output string) {
-	output = ""
+// relativeTo returns target relative to dir with forward slashes, as used in
+// package.json and tsconfig.json. Both are made absolute first, so a relative
+// dir and an absolute target still give a relative path, and an error is
+// returned rather than a path only valid on this machine.
+func relativeTo(dir string, target string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	absTarget, err := filepath.Abs(target)
+	if err != nil {
+		return "", err
+	}
+	rel, err := filepath.Rel(absDir, absTarget)
+	if err != nil {
+		return "", err
+	}
+	return filepath.ToSlash(rel), nil
+}
 
-	if iscamelToSnake(input) {
-		output = input
-		return
+// newPackageJson returns the package.json written to path for the client.
+// Entry points are resolved relative to path, so the package may live above
+// the output directory.
+func newPackageJson(path string, cfg Config, schema Schema) (PackageJson, error) {
+	name := cfg.TsNamespace
+	if name == "" {
+		name = cfg.Prefix + cfg.Namespace
 	}
 
-	for _, v := range input {
-		vString := string(v)
-		if vString == strings.ToUpper(vString) {
-			output += "_" + strings.ToLower(vString)
-		} else {
-			output += vString
+	dir := filepath.Dir(path)
+	source := clientModule(cfg)
+	base := strings.TrimSuffix(filepath.Base(source), ".ts")
+	// tsc writes into "dist" next to the tsconfig.json, or next to the
+	// package.json when compiling from the command line.
+	dist := "dist"
+	tsconfig := cfg.EmitTsconfig
+	if tsconfig != "" {
+		var err error
+		if dist, err = relativeTo(dir, filepath.Join(filepath.Dir(tsconfig), "dist")); err != nil {
+			return PackageJson{}, err
 		}
 	}
+	relSource, err := relativeTo(dir, source)
+	if err != nil {
+		return PackageJson{}, err
+	}
 
-	return
+	entry := PackageExport{Types: "./" + dist + "/" + base + ".d.ts", Import: "./" + dist + "/" + base + ".js"}
+	moduleType, module := "module", "es2020"
+	switch cfg.ModuleFormat {
+	case "cjs":
+		moduleType, module = "commonjs", "commonjs"
+	case "umd":
+		moduleType, module = "commonjs", "umd"
+	}
+	if moduleType == "commonjs" {
+		entry.Require, entry.Import = entry.Import, ""
+	}
+
+	pkg := PackageJson{
+		Name:            strings.ReplaceAll(snakeCase(nonIdentifier.ReplaceAllString(name, "_")), "_", "-"),
+		Version:         packageVersion(schema.Info.Version),
+		Description:     "TypeScript client for the " + name + " API.",
+		Type:            moduleType,
+		Main:            dist + "/" + base + ".js",
+		Types:           dist + "/" + base + ".d.ts",
+		Exports:         map[string]PackageExport{".": entry},
+		Files:           []string{dist},
+		Scripts:         map[string]string{"build": "tsc --declaration --module " + module + " --outDir dist " + relSource},
+		Dependencies:    map[string]string{"js-base64": "^3.7.4"},
+		DevDependencies: map[string]string{"typescript": "^4.9.4"},
+	}
+	if tsconfig != "" {
+		// moduleResolution "bundler" needs TypeScript 5.
+		rel, err := relativeTo(dir, tsconfig)
+		if err != nil {
+			return PackageJson{}, err
+		}
+		pkg.Scripts["build"] = "tsc"
+		if rel != "tsconfig.json" {
+			pkg.Scripts["build"] = "tsc -p " + rel
+		}
+		pkg.DevDependencies["typescript"] = "^5.0.0"
+	}
+	if cfg.EmitZod {
+		pkg.Dependencies["zod"] = "^3.0.0"
+	}
+	if cfg.EmitIoTs {
+		pkg.Dependencies["io-ts"] = "^2.2.0"
+		pkg.Dependencies["fp-ts"] = "^2.0.0"
+	}
+
+	peers := make(map[string]string)
+	if cfg.EmitReactHooks {
+		peers["react"] = ">=16.8.0"
+	}
+	if cfg.EmitVueComposables {
+		peers["@vue/runtime-core"] = "^3.0.0"
+	}
+	if cfg.EmitAngular {
+		peers["@angular/core"] = ">=14.0.0"
+	}
+	if cfg.EmitRxjs || cfg.EmitAngular {
+		peers["rxjs"] = "^7.0.0"
+	}
+	if len(peers) > 0 {
+		pkg.PeerDependencies = peers
+		for name, version := range peers {
+			pkg.DevDependencies[name] = version
+		}
+	}
+	return pkg, nil
 }
 
-// pascalToCamel converts a Pascal case string to a camel case string.
-func pascalToCamel(input string) (camelCase string) {
-	if input == "" {
-		return ""
+// writePackageJson writes the package.json of the client to path.
+func writePackageJson(path string, cfg Config, schema Schema) error {
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	pkg, err := newPackageJson(path, cfg, schema)
+	if err != nil {
+		return err
+	}
+	if err := encoder.Encode(pkg); err != nil {
+		return err
 	}
+	return os.WriteFile(path, buf.Bytes(), 0644)
+}
 
-	camelCase = strings.ToLower(string(input[0]))
-	camelCase += string(input[1:])
-	return camelCase
+// Tsconfig is the tsconfig.json of the generated client.
+type Tsconfig struct {
+	CompilerOptions struct {
+		Target           string `json:"target"`
+		Module           string `json:"module"`
+		ModuleResolution string `json:"moduleResolution"`
+		Strict           bool   `json:"strict"`
+		StrictNullChecks bool   `json:"strictNullChecks"`
+		Declaration      bool   `json:"declaration"`
+		OutDir           string `json:"outDir"`
+	} `json:"compilerOptions"`
+	Include []string `json:"include"`
 }
 
-func replace(input, from, to string) string {
-	return strings.Replace(input, from, to, -1)
+// newTsconfig returns the tsconfig.json written to path for the client,
+// including the generated modules relative to path.
+func newTsconfig(path string, cfg Config) (Tsconfig, error) {
+	var tsconfig Tsconfig
+	options := &tsconfig.CompilerOptions
+	options.Target = "ES2020"
+	options.Module = "ESNext"
+	options.ModuleResolution = "bundler"
+	// the generated code uses ES module syntax, which tsc compiles into the
+	// module format.
+	switch cfg.ModuleFormat {
+	case "cjs":
+		options.Module = "CommonJS"
+		options.ModuleResolution = "node"
+	case "umd":
+		options.Module = "UMD"
+		options.ModuleResolution = "node"
+	}
+	// strict implies strictNullChecks, which the generated code only
+	// satisfies with --strict, so it is always written out.
+	options.Strict = true
+	options.StrictNullChecks = cfg.Strict
+	options.Declaration = true
+	options.OutDir = "dist"
+	dir := filepath.Dir(path)
+	if cfg.SplitByTag {
+		rel, err := relativeTo(dir, cfg.OutputDir)
+		if err != nil {
+			return Tsconfig{}, err
+		}
+		tsconfig.Include = []string{rel + "/*.ts"}
+	} else {
+		rel, err := relativeTo(dir, cfg.Output)
+		if err != nil {
+			return Tsconfig{}, err
+		}
+		tsconfig.Include = []string{rel}
+	}
+	return tsconfig, nil
 }
 
-func main() {
-	// Argument flags
-	var output = flag.String("output", "", "The output for generated code.")
-	flag.Parse()
-
-	inputs := flag.Args()
-	if len(inputs) < 1 {
-		fmt.Printf("No input file found: %s\n", inputs)
-		flag.PrintDefaults()
-		return
+// writeTsconfig writes the tsconfig.json of the client to path and prints the
+// path unless cfg.Quiet is set.
+func writeTsconfig(path string, cfg Config) error {
+	tsconfig, err := newTsconfig(path, cfg)
+	if err != nil {
+		return err
+	}
+	data, err := json.MarshalIndent(tsconfig, "", "  ")
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return err
+	}
+	if !cfg.Quiet {
+		fmt.Println(path)
+	}
+	return nil
+}
+
+// exportedDeclaration matches the top-level exported declarations of
+// generated code, capturing their kind and name.
+var exportedDeclaration = regexp.MustCompile(`(?m)^export (?:declare )?(const enum|enum|interface|type|class|const|function|let) (\w+)`)
+
+// exportedSymbols returns the names exported by generated code, mapped to
+// whether they are types only, e.g. interfaces, which have no runtime value.
+func exportedSymbols(code string) map[string]bool {
+	symbols := make(map[string]bool)
+	for _, match := range exportedDeclaration.FindAllStringSubmatch(code, -1) {
+		symbols[match[2]] = match[1] == "interface" || match[1] == "type"
+	}
+	return symbols
+}
+
+var identifier = regexp.MustCompile(`[A-Za-z_$][\w$]*`)
+
+// stripCommentsAndStrings returns code with its comments and the text of its
+// string and template literals replaced by spaces, leaving the expressions of
+// template literals in place.
+func stripCommentsAndStrings(code string) string {
+	var b strings.Builder
+	// the open braces in each ${} expression of the template literals being read.
+	var braces []int
+	for i := 0; i < len(code); i++ {
+		c := code[i]
+		switch {
+		case strings.HasPrefix(code[i:], "//"):
+			end := strings.IndexByte(code[i:], '\n')
+			if end < 0 {
+				end = len(code) - i
+			}
+			i += end - 1
+			b.WriteByte(' ')
+		case strings.HasPrefix(code[i:], "/*"):
+			end := strings.Index(code[i+2:], "*/")
+			if end < 0 {
+				i = len(code)
+			} else {
+				i += end + 3
+			}
+			b.WriteByte(' ')
+		case c == '"' || c == '\'':
+			for i++; i < len(code) && code[i] != c && code[i] != '\n'; i++ {
+				if code[i] == '\\' {
+					i++
+				}
+			}
+			b.WriteByte(' ')
+		case c == '`' || c == '}' && len(braces) > 0 && braces[len(braces)-1] == 0:
+			if c == '}' {
+				braces = braces[:len(braces)-1]
+			}
+			for i++; i < len(code) && code[i] != '`'; i++ {
+				if code[i] == '\\' {
+					i++
+				} else if strings.HasPrefix(code[i:], "${") {
+					braces = append(braces, 0)
+					i++
+					break
+				}
+			}
+			b.WriteByte(' ')
+		case c == '{' && len(braces) > 0:
+			braces[len(braces)-1]++
+			b.WriteByte(c)
+		case c == '}' && len(braces) > 0:
+			braces[len(braces)-1]--
+			b.WriteByte(c)
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// importSymbols returns the import statements of the symbols of module which
+// code refers to outside of comments and strings. With importType, types are
+// imported with "import type".
+func importSymbols(code string, symbols map[string]bool, module string, importType bool) string {
+	used := make(map[string]bool)
+	for _, name := range identifier.FindAllString(stripCommentsAndStrings(code), -1) {
+		if _, ok := symbols[name]; ok {
+			used[name] = true
+		}
+	}
+
+	var types, values []string
+	for name := range used {
+		if symbols[name] && importType {
+			types = append(types, name)
+		} else {
+			values = append(values, name)
+		}
+	}
+	sort.Strings(types)
+	sort.Strings(values)
+
+	var imports string
+	if len(values) > 0 {
+		imports += fmt.Sprintf("import { %s } from '%s';\n", strings.Join(values, ", "), module)
 	}
+	if len(types) > 0 {
+		imports += fmt.Sprintf("import type { %s } from '%s';\n", strings.Join(types, ", "), module)
+	}
+	return imports
+}
+
+// writeWithImports executes a template and writes the result to path, with
+// imports of the symbols from definitions.ts it refers to added after its
+// own imports. With importType, types are imported with "import type".
+// Unchanged files are skipped with hashes.
+func writeWithImports(ctx context.Context, path string, tmpl *template.Template, data interface{}, symbols map[string]bool, importType bool, hashes *splitHashes) error {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(contextWriter{ctx, &buf}, data); err != nil {
+		return err
+	}
+	code := buf.String()
 
-	input := inputs[0]
-	content, err := ioutil.ReadFile(input)
+	imports := importSymbols(code, symbols, "./definitions", importType)
+	if i := strings.LastIndex(code, "\nimport "); i >= 0 {
+		end := i + 1 + strings.Index(code[i+1:], "\n") + 1
+		code = code[:end] + imports + code[end:]
+	} else {
+		code = imports + code
+	}
+	return hashes.write(path, []byte(code))
+}
+
+// isolatedModules reports whether a tsconfig.json enables isolatedModules,
+// under which interfaces must be imported with "import type".
+func isolatedModules(path string) (bool, error) {
+	content, err := os.ReadFile(path)
 	if err != nil {
-		fmt.Printf("Unable to read file: %s\n", err)
-		return
+		return false, err
+	}
+	var tsconfig struct {
+		CompilerOptions struct {
+			IsolatedModules bool
+		}
+	}
+	if err := json.Unmarshal(stripJsonComments(content), &tsconfig); err != nil {
+		return false, err
+	}
+	return tsconfig.CompilerOptions.IsolatedModules, nil
+}
+
+// trailingComma matches a comma before a closing bracket, which tsconfig.json
+// allows but JSON does not.
+var trailingComma = regexp.MustCompile(`,(\s*[}\]])`)
+
+// stripJsonComments removes the comments and trailing commas of JSON with
+// comments, as tsconfig.json files are, leaving strings intact.
+func stripJsonComments(content []byte) []byte {
+	var out []byte
+	inString := false
+	for i := 0; i < len(content); i++ {
+		c := content[i]
+		switch {
+		case inString:
+			out = append(out, c)
+			if c == '\\' && i+1 < len(content) {
+				i++
+				out = append(out, content[i])
+			} else if c == '"' {
+				inString = false
+			}
+		case c == '"':
+			inString = true
+			out = append(out, c)
+		case c == '/' && i+1 < len(content) && content[i+1] == '/':
+			for i < len(content) && content[i] != '\n' {
+				i++
+			}
+			i--
+		case c == '/' && i+1 < len(content) && content[i+1] == '*':
+			end := bytes.Index(content[i+2:], []byte("*/"))
+			if end < 0 {
+				return out
+			}
+			i += end + 3
+		default:
+			out = append(out, c)
+		}
 	}
+	return trailingComma.ReplaceAll(out, []byte("$1"))
+}
+
+// stringList is a flag which can be given several times.
+type stringList []string
+
+func (l *stringList) String() string {
+	return strings.Join(*l, ",")
+}
+
+func (l *stringList) Set(value string) error {
+	*l = append(*l, value)
+	return nil
+}
+
+// refPrefixes are the sections a "$ref" value can point into.
+var refPrefixes = []string{"#/definitions/", "#/$defs/", "#/components/schemas/"}
 
-	var namespace (string) = ""
+// refName returns the definition name a "$ref" value points to, in any of
+// the "definitions", "$defs" or OpenAPI 3 "components/schemas" sections.
+func refName(ref string) string {
+	for _, prefix := range refPrefixes {
+		if strings.HasPrefix(ref, prefix) {
+			return strings.TrimPrefix(ref, prefix)
+		}
+	}
+	return ref
+}
 
-	if len(inputs) > 1 {
-		if len(inputs[1]) <= 0 {
-			fmt.Println("Empty Namespace provided.")
+// reachableDefinitions returns the names of all definitions referenced by the
+// given paths, following references between definitions.
+func reachableDefinitions(paths map[string]map[string]Operation, definitions map[string]Definition) map[string]bool {
+	reachable := make(map[string]bool)
+	var queue []string
+	visit := func(ref string) {
+		if ref == "" {
 			return
 		}
+		name := refName(ref)
+		if !reachable[name] {
+			reachable[name] = true
+			queue = append(queue, name)
+		}
+	}
+
+	for _, path := range paths {
+		for _, operation := range path {
+			visit(operation.Responses.Ok.Schema.Ref)
+			for _, parameter := range operation.Parameters {
+				visit(parameter.Schema.Ref)
+			}
+		}
+	}
 
-		namespace = inputs[1]
+	for len(queue) > 0 {
+		name := queue[0]
+		queue = queue[1:]
+		for _, property := range definitions[name].Properties {
+			visit(property.Ref)
+			visit(property.Items.Ref)
+		}
 	}
 
-	var schema struct {
-		Namespace string
-		Paths     map[string]map[string]struct {
-			Summary     string
-			OperationId string
-			Responses   struct {
-				Ok struct {
-					Schema struct {
-						Ref string `json:"$ref"`
-					}
-				} `json:"200"`
-			}
-			Parameters []struct {
-				Name     string
-				In       string
-				Required bool
-				Type     string   // used with primitives
-				Items    struct { // used with type "array"
-					Type string
-				}
-				Schema struct { // used with http body
-					Type string
-					Ref  string `json:"$ref"`
-				}
+	return reachable
+}
+
+// filterByTag removes all operations which have none of the given tags, then
+// removes the definitions no remaining operation refers to.
+func filterByTag(schema *Schema, tags []string) {
+	wanted := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		wanted[tag] = true
+	}
+
+	for url, path := range schema.Paths {
+		for method, operation := range path {
+			keep := false
+			for _, tag := range operation.Tags {
+				keep = keep || wanted[tag]
+			}
+			if !keep {
+				delete(path, method)
 			}
-			Security []map[string][]struct{}
 		}
-		Definitions map[string]Definition
+		if len(path) == 0 {
+			delete(schema.Paths, url)
+		}
 	}
 
-	if err := json.Unmarshal(content, &schema); err != nil {
-		fmt.Printf("Unable to decode input %s : %s\n", input, err)
-		return
+	pruneDefinitions(schema)
+}
+
+// filterByPathPrefix removes all paths which start with none of the given
+// prefixes, then removes the definitions no remaining operation refers to.
+func filterByPathPrefix(schema *Schema, prefixes []string) {
+	for url := range schema.Paths {
+		keep := false
+		for _, prefix := range prefixes {
+			keep = keep || strings.HasPrefix(url, prefix)
+		}
+		if !keep {
+			delete(schema.Paths, url)
+		}
 	}
 
-	schema.Namespace = namespace
+	pruneDefinitions(schema)
+}
 
-	fmap := template.FuncMap{
-		"enumDescriptions": enumDescriptions,
-		"enumSummary":      enumSummary,
-		"snakeToCamel":     snakeToCamel,
-		"cleanRef":         convertRefToClassName,
-		"isRefToEnum": func(ref string) bool {
-			// swagger schema definition keys have inconsistent casing
-			var camelOk bool
-			var pascalOk bool
-			var enums []string
+// pruneDefinitions removes the definitions which are not reachable from any
+// operation of the schema and returns how many were removed.
+func pruneDefinitions(schema *Schema) int {
+	reachable := reachableDefinitions(schema.Paths, schema.Definitions)
+	pruned := 0
+	for name := range schema.Definitions {
+		if !reachable[name] {
+			delete(schema.Definitions, name)
+			pruned++
+		}
+	}
+	return pruned
+}
 
-			asCamel := pascalToCamel(ref)
-			if _, camelOk = schema.Definitions[asCamel]; camelOk {
-				enums = schema.Definitions[asCamel].Enum
+// removeDeprecated removes all deprecated operations and definition fields.
+func removeDeprecated(schema *Schema) {
+	for url, path := range schema.Paths {
+		for method, operation := range path {
+			if isDeprecated(operation) {
+				delete(path, method)
 			}
+		}
+		if len(path) == 0 {
+			delete(schema.Paths, url)
+		}
+	}
 
-			asPascal := camelToPascal(ref)
-			if _, pascalOk = schema.Definitions[asPascal]; pascalOk {
-				enums = schema.Definitions[asPascal].Enum
+	for _, definition := range schema.Definitions {
+		for key, property := range definition.Properties {
+			if property.Deprecated {
+				delete(definition.Properties, key)
 			}
+		}
+	}
+}
 
-			if !pascalOk && !camelOk {
-				fmt.Printf("no definition found: %v", ref)
-				return false
+// compareSchemas lists the differences between two versions of a
+// specification. Breaking changes are those which can fail code written
+// against the old version: removed operations and definition fields, changed
+// parameter, response and field types, and new required parameters.
+func compareSchemas(old, current Schema) (breaking, additions []string) {
+	oldOperations := operationsById(old)
+	newOperations := operationsById(current)
+
+	for id, oldOperation := range oldOperations {
+		newOperation, ok := newOperations[id]
+		if !ok {
+			breaking = append(breaking, fmt.Sprintf("operation %s was removed", id))
+			continue
+		}
+
+		operationBreaking, operationAdditions := compareOperations(oldOperation, newOperation)
+		for _, change := range operationBreaking {
+			breaking = append(breaking, "operation "+id+" "+change)
+		}
+		for _, change := range operationAdditions {
+			additions = append(additions, "operation "+id+" "+change)
+		}
+	}
+	for id := range newOperations {
+		if _, ok := oldOperations[id]; !ok {
+			additions = append(additions, fmt.Sprintf("operation %s was added", id))
+		}
+	}
+
+	for name, oldDefinition := range old.Definitions {
+		newDefinition, ok := current.Definitions[name]
+		if !ok {
+			breaking = append(breaking, fmt.Sprintf("definition %s was removed", name))
+			continue
+		}
+		for key, oldProperty := range oldDefinition.Properties {
+			newProperty, ok := newDefinition.Properties[key]
+			if !ok {
+				breaking = append(breaking, fmt.Sprintf("definition %s field %s was removed", name, key))
+			} else if before, after := propertyType(oldProperty), propertyType(newProperty); before != after {
+				breaking = append(breaking, fmt.Sprintf("definition %s field %s changed from %s to %s", name, key, before, after))
+			}
+		}
+		for key := range newDefinition.Properties {
+			if _, ok := oldDefinition.Properties[key]; !ok {
+				additions = append(additions, fmt.Sprintf("definition %s field %s was added", name, key))
 			}
+		}
+	}
+	for name := range current.Definitions {
+		if _, ok := old.Definitions[name]; !ok {
+			additions = append(additions, fmt.Sprintf("definition %s was added", name))
+		}
+	}
 
-			return len(enums) > 0
-		},
-		"title":                strings.Title,
-		"camelToSnake":         camelToSnake,
-		"uppercase":            strings.ToUpper,
-		"stripOperationPrefix": stripOperationPrefix,
-		"replace":              replace,
+	sort.Strings(breaking)
+	sort.Strings(additions)
+	return breaking, additions
+}
+
+// compareOperations lists the differences between two versions of an
+// operation, split like those of compareSchemas.
+func compareOperations(old, current Operation) (breaking, additions []string) {
+	if before, after := returnType(old, ""), returnType(current, ""); before != after {
+		breaking = append(breaking, fmt.Sprintf("response changed from %s to %s", before, after))
 	}
 
-	tmpl, err := template.New(input).Funcs(fmap).Parse(codeTemplate)
-	if err != nil {
-		fmt.Printf("Template parse error: %s\n", err)
-		return
+	oldParameters := make(map[string]Parameter)
+	for _, parameter := range old.Parameters {
+		oldParameters[parameter.Name] = parameter
+	}
+	for _, parameter := range current.Parameters {
+		oldParameter, ok := oldParameters[parameter.Name]
+		delete(oldParameters, parameter.Name)
+		switch {
+		case !ok && (parameter.Required || parameter.In == "path"):
+			breaking = append(breaking, "has new required parameter "+parameter.Name)
+		case !ok:
+			additions = append(additions, "has new parameter "+parameter.Name)
+		case parameterType(oldParameter, "", false) != parameterType(parameter, "", false):
+			breaking = append(breaking, fmt.Sprintf("parameter %s changed from %s to %s", parameter.Name,
+				parameterType(oldParameter, "", false), parameterType(parameter, "", false)))
+		case parameter.Required && !oldParameter.Required:
+			breaking = append(breaking, "parameter "+parameter.Name+" is now required")
+		}
+	}
+	for name := range oldParameters {
+		breaking = append(breaking, "parameter "+name+" was removed")
 	}
 
-	if len(*output) < 1 {
-		tmpl.Execute(os.Stdout, schema)
-		return
+	sort.Strings(breaking)
+	sort.Strings(additions)
+	return breaking, additions
+}
+
+// changelog renders the operation changes between two versions of a
+// specification as Markdown in the Keep a Changelog format.
+func changelog(old, current Schema) string {
+	oldOperations := operationsById(old)
+	newOperations := operationsById(current)
+	oldPaths := operationPaths(old)
+	newPaths := operationPaths(current)
+
+	var added, changed, deprecated, removed []string
+	for id, newOperation := range newOperations {
+		entry := "`" + id + "` `" + strings.Join(newPaths[id], "`, `") + "`"
+		oldOperation, ok := oldOperations[id]
+		if !ok {
+			added = append(added, entry)
+			continue
+		}
+		if isDeprecated(newOperation) && !isDeprecated(oldOperation) {
+			deprecated = append(deprecated, entry)
+		}
+		breaking, additions := compareOperations(oldOperation, newOperation)
+		if changes := append(breaking, additions...); len(changes) > 0 {
+			changed = append(changed, entry+": "+strings.Join(changes, ", "))
+		}
+	}
+	for id := range oldOperations {
+		if _, ok := newOperations[id]; !ok {
+			removed = append(removed, "`"+id+"` `"+strings.Join(oldPaths[id], "`, `")+"`")
+		}
 	}
 
-	f, err := os.Create(*output)
-	if err != nil {
-		fmt.Printf("Unable to create file %s", err)
-		return
+	var sb strings.Builder
+	sb.WriteString("# Changelog\n\n## [Unreleased]\n")
+	for _, section := range []struct {
+		title   string
+		entries []string
+	}{{"Added", added}, {"Changed", changed}, {"Deprecated", deprecated}, {"Removed", removed}} {
+		if len(section.entries) == 0 {
+			continue
+		}
+		sort.Strings(section.entries)
+		sb.WriteString("\n### " + section.title + "\n\n")
+		for _, entry := range section.entries {
+			sb.WriteString("- " + entry + "\n")
+		}
 	}
-	defer f.Close()
+	return sb.String()
+}
+
+// isDeprecated reports whether an operation is marked deprecated.
+func isDeprecated(operation Operation) bool {
+	return operation.Deprecated || operation.XDeprecatedReason != ""
+}
+
+// operationsById indexes the operations of a specification by operationId.
+func operationsById(schema Schema) map[string]Operation {
+	operations := make(map[string]Operation)
+	for _, path := range schema.Paths {
+		for _, operation := range path {
+			operations[operation.OperationId] = operation
+		}
+	}
+	return operations
+}
 
-	writer := bufio.NewWriter(f)
-	tmpl.Execute(writer, schema)
-	writer.Flush()
+// propertyType describes the type of a definition field, such as "string
+// (date-time)" or "array of apiUser".
+func propertyType(property Property) string {
+	switch {
+	case property.Ref != "":
+		return refName(property.Ref)
+	case property.Type == "array" && property.Items.Ref != "":
+		return "array of " + refName(property.Items.Ref)
+	case property.Type == "array":
+		return "array of " + property.Items.Type
+	case property.Type == "object" && property.AdditionalProperties.Type != "":
+		return "map of " + property.AdditionalProperties.Type
+	case property.Format != "":
+		return property.Type + " (" + property.Format + ")"
+	default:
+		return property.Type
+	}
 }
@@ -0,0 +1,2305 @@
+// Copyright 2018 The Nakama Authors
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+// http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"runtime"
+	"sort"
+	"strings"
+	"testing"
+	"text/template"
+	"time"
+)
+
+var updateGolden = flag.Bool("update-golden", false, "Rewrite the golden files in testdata with the generated output.")
+
+func TestMain(m *testing.M) {
+	// runGenerator re-executes the test binary with this variable set to run
+	// the generator itself in a child process.
+	if args := os.Getenv("OPENAPI_GEN_ARGS"); args != "" {
+		os.Args = append([]string{"openapi-gen"}, strings.Split(args, "\n")...)
+		main()
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+// generatorCommand returns a command running the generator with the given
+// arguments in a child process.
+func generatorCommand(t *testing.T, args ...string) *exec.Cmd {
+	t.Helper()
+
+	// generateOutput overwrites os.Args, so os.Args[0] is not reliable here.
+	executable, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command(executable)
+	cmd.Env = append(os.Environ(), "OPENAPI_GEN_ARGS="+strings.Join(args, "\n"))
+	return cmd
+}
+
+// runGenerator runs the generator with the given arguments in a child
+// process, so that tests can observe its exit status.
+func runGenerator(t *testing.T, args ...string) ([]byte, error) {
+	t.Helper()
+	return generatorCommand(t, args...).CombinedOutput()
+}
+
+// generateOutput runs the generator with the given arguments and returns the
+// rendered output.
+func generateOutput(t *testing.T, args ...string) string {
+	t.Helper()
+
+	output := filepath.Join(t.TempDir(), "api.gen.ts")
+	os.Args = append([]string{"openapi-gen", "-output", output}, args...)
+	flag.CommandLine = flag.NewFlagSet(os.Args[0], flag.ExitOnError)
+	main()
+
+	content, err := os.ReadFile(output)
+	if err != nil {
+		t.Fatalf("unable to read generated output: %s", err)
+	}
+	return string(content)
+}
+
+// assertGolden compares generated output against a golden file in testdata,
+// or rewrites the golden file when the -update-golden flag is given.
+func assertGolden(t *testing.T, got string, golden string) {
+	t.Helper()
+
+	if *updateGolden {
+		if err := os.WriteFile(filepath.Join("testdata", golden), []byte(got), 0644); err != nil {
+			t.Fatalf("unable to update golden file: %s", err)
+		}
+		return
+	}
+
+	want, err := os.ReadFile(filepath.Join("testdata", golden))
+	if err != nil {
+		t.Fatalf("unable to read golden file: %s", err)
+	}
+	if got != string(want) {
+		t.Errorf("generated output does not match testdata/%s:\n%s", golden, got)
+	}
+}
+
+func TestSnakeCase(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", ""},
+		{"user", "user"},
+		{"User", "user"},
+		{"userId", "user_id"},
+		{"UserID", "user_id"},
+		{"HTTPSProxy", "https_proxy"},
+		{"ApiAccountID", "api_account_id"},
+		{"getHTTPResponse", "get_http_response"},
+		{"ID", "id"},
+		{"Base64Encoded", "base64_encoded"},
+		{"Oauth2Token", "oauth2_token"},
+		{"already_snake", "already_snake"},
+		{"Mixed_CaseID", "mixed_case_id"},
+	}
+
+	for _, tt := range tests {
+		if got := snakeCase(tt.input); got != tt.want {
+			t.Errorf("snakeCase(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestGolden(t *testing.T) {
+	tests := []struct {
+		fixture string
+		flags   []string
+		golden  string
+	}{
+		{"all_types", nil, "all_types.ts.golden"},
+		{"body_parameters", nil, "body_parameters.ts.golden"},
+		{"integer_map", nil, "integer_map.ts.golden"},
+		{"integer_map", []string{"-emit-response-types"}, "integer_map.response_types.ts.golden"},
+		{"no_content", nil, "no_content.ts.golden"},
+		{"path_parameters", nil, "path_parameters.ts.golden"},
+		{"upload", nil, "upload.ts.golden"},
+		{"sse", nil, "sse.ts.golden"},
+		{"all_types", []string{"-date-reviver"}, "all_types.date_reviver.ts.golden"},
+		{"all_types", []string{"-no-bigint"}, "all_types.no_bigint.ts.golden"},
+		{"all_types", []string{"-emit-io-ts", "-date-reviver"}, "all_types.io_ts.ts.golden"},
+		{"path_parameters", []string{"-emit-mock"}, "path_parameters.mock.ts.golden"},
+		{"path_parameters", []string{"-emit-react-hooks"}, "path_parameters.react_hooks.ts.golden"},
+		{"path_parameters", []string{"-emit-vue-composables"}, "path_parameters.vue_composables.ts.golden"},
+		{"path_parameters", []string{"-emit-rxjs"}, "path_parameters.rxjs.ts.golden"},
+		{"path_parameters", []string{"-emit-angular"}, "path_parameters.angular.ts.golden"},
+		{"path_parameters", []string{"-emit-zod", "-validate-responses"}, "path_parameters.validate_responses.ts.golden"},
+		{"all_types", []string{"-emit-type-guards"}, "all_types.type_guards.ts.golden"},
+		{"dedup", []string{"-emit-dedup"}, "dedup.ts.golden"},
+		{"offline_queue", []string{"-emit-offline-queue"}, "offline_queue.ts.golden"},
+		// without offline-safe operations there is nothing to queue.
+		{"path_parameters", []string{"-emit-offline-queue"}, "path_parameters.ts.golden"},
+		{"health_check", []string{"-emit-health-check"}, "health_check.ts.golden"},
+	}
+
+	for _, tt := range tests {
+		t.Run(strings.TrimSuffix(tt.golden, ".ts.golden"), func(t *testing.T) {
+			args := append(tt.flags, filepath.Join("testdata", tt.fixture+".swagger.json"), "Nakama")
+			got := generateOutput(t, args...)
+			assertGolden(t, got, tt.golden)
+		})
+	}
+}
+
+func TestMultipartParameters(t *testing.T) {
+	schema, err := parseSchema([]byte(`{
+		"paths": {
+			"/v2/upload": {"post": {"operationId": "Nakama_Upload", "consumes": ["multipart/form-data"], "parameters": [
+				{"name": "file", "in": "formData", "required": true, "type": "file"},
+				{"name": "key", "in": "formData", "type": "string"}
+			]}},
+			"/v2/import": {"post": {"operationId": "Nakama_Import", "consumes": ["multipart/form-data"]}}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	upload := schema.Paths["/v2/upload"]["post"].Parameters
+	if len(upload) != 2 || !upload[0].File || upload[1].File || upload[0].Multipart {
+		t.Errorf("form parameters are %+v, want a file and a string appended to FormData", upload)
+	}
+	body := schema.Paths["/v2/import"]["post"].Parameters
+	if len(body) != 1 || !body[0].Multipart || !body[0].Required || body[0].In != "body" {
+		t.Errorf("parameters without form fields are %+v, want a required FormData body", body)
+	}
+}
+
+func TestErrorResponse(t *testing.T) {
+	got := generateOutput(t, filepath.Join("testdata", "upload.swagger.json"), "Nakama")
+	doFetch := got[strings.Index(got, "  doFetch("):strings.Index(got, "  async signRequest(")]
+	doXhr := got[strings.Index(got, "  doXhr("):strings.Index(got, "  buildFullUrl(")]
+
+	// both reject failed requests with the Response, its body parsed.
+	if !strings.Contains(doFetch, `throw Object.defineProperty(response, "body", {value: body});`) {
+		t.Error("doFetch does not reject with the response")
+	}
+	if !strings.Contains(doXhr, "new Response(null, {status: xhr.status, statusText: xhr.statusText, headers: headers})") ||
+		!strings.Contains(doXhr, "reject(Object.defineProperties(response, {body: {value: body}, url: {value: xhr.responseURL}}));") {
+		t.Error("doXhr does not reject with a response like doFetch")
+	}
+	if strings.Contains(doXhr, "reject(xhr)") {
+		t.Error("doXhr rejects with the XMLHttpRequest")
+	}
+}
+
+func TestSignRequest(t *testing.T) {
+	got := generateOutput(t, filepath.Join("testdata", "upload.swagger.json"), "Nakama")
+	doFetch := got[strings.Index(got, "  doFetch("):strings.Index(got, "  async signRequest(")]
+	doXhr := got[strings.Index(got, "  doXhr("):strings.Index(got, "  buildFullUrl(")]
+
+	// requests with progress callbacks are signed too, before their headers are set.
+	for name, method := range map[string]string{"doFetch": doFetch, "doXhr": doXhr} {
+		if !strings.Contains(method, "this.signRequest(fullUrl, ") {
+			t.Errorf("%s does not sign its requests", name)
+		}
+	}
+	if strings.Index(doXhr, "this.signRequest(") > strings.Index(doXhr, "xhr.setRequestHeader(") {
+		t.Error("doXhr sets the request headers before signing them")
+	}
+}
+
+func TestResponseType(t *testing.T) {
+	tests := []struct {
+		produces []string
+		want     string
+	}{
+		{nil, "json"},
+		{[]string{"application/json"}, "json"},
+		{[]string{"text/plain"}, "text"},
+		{[]string{"text/plain", "application/json"}, "json"},
+		{[]string{"application/octet-stream"}, "blob"},
+		{[]string{"application/octet-stream", "application/json"}, "json"},
+	}
+
+	for _, tt := range tests {
+		if got := responseType(tt.produces); got != tt.want {
+			t.Errorf("responseType(%q) = %q, want %q", tt.produces, got, tt.want)
+		}
+	}
+}
+
+func TestReturnType(t *testing.T) {
+	var empty, ref, object, text Operation
+	ref.Responses.Ok.Schema.Ref = "#/definitions/apiUser"
+	object.Responses.Ok.Schema.Type = "object"
+	text.Produces = []string{"text/plain"}
+
+	tests := []struct {
+		name      string
+		operation Operation
+		want      string
+	}{
+		{"empty schema", empty, "void"},
+		{"reference", ref, "ApiUser"},
+		{"inline object", object, "any"},
+		{"text", text, "string"},
+	}
+	for _, tt := range tests {
+		if got := returnType(tt.operation, ""); got != tt.want {
+			t.Errorf("%s: returnType() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestIndentTab(t *testing.T) {
+	got := generateOutput(t, "-indent", "tab", filepath.Join("testdata", "integer_map.swagger.json"), "Nakama")
+	for i, line := range strings.Split(got, "\n") {
+		if strings.HasPrefix(line, " ") {
+			t.Errorf("line %d is indented with spaces: %q", i+1, line)
+		}
+	}
+}
+
+func TestPrefix(t *testing.T) {
+	got := generateOutput(t, "-prefix", "Nk", filepath.Join("testdata", "body_parameters.swagger.json"), "Nakama")
+	for _, want := range []string{"export class NkNakamaApi", "export interface NkApi"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	if regexp.MustCompile(`\bApi[A-Z]`).MatchString(got) {
+		t.Errorf("output refers to a definition without the prefix")
+	}
+}
+
+func TestNamespace(t *testing.T) {
+	got := generateOutput(t, "-namespace", "Nakama", filepath.Join("testdata", "integer_map.swagger.json"), "Nakama")
+	start := strings.Index(got, "export namespace Nakama {\n")
+	if start < 0 || start > strings.Index(got, "export const SDK_VERSION") {
+		t.Fatalf("output does not open the namespace before the generated code:\n%s", got)
+	}
+	if strings.Contains(got[start:], "import ") {
+		t.Errorf("imports are emitted inside the namespace")
+	}
+	if !strings.HasSuffix(got, "}\n\n}\n") && !strings.HasSuffix(got, "};\n\n}\n") {
+		t.Errorf("output does not close the namespace after the generated code")
+	}
+}
+
+func TestEmitDefaults(t *testing.T) {
+	got := generateOutput(t, "-emit-defaults", filepath.Join("testdata", "all_types.swagger.json"), "Nakama")
+	want := `export const defaultApiThing: Partial<ApiThing> = {
+  count: 10,
+  enabled: true,
+  total: BigInt("0"),
+};`
+	if !strings.Contains(got, want) {
+		t.Errorf("output does not contain the defaults of ApiThing:\n%s", got)
+	}
+	if !strings.Contains(got, "export const defaultConfiguration: Required<ConfigurationParameters> = {") {
+		t.Errorf("output does not contain defaultConfiguration")
+	}
+	if strings.Contains(got, "defaultApiUserData") {
+		t.Errorf("output contains the defaults of a definition without any")
+	}
+
+	// the client falls back to the same defaults, which are internal unless emitted.
+	got = generateOutput(t, "-strict", filepath.Join("testdata", "all_types.swagger.json"), "Nakama")
+	if !strings.Contains(got, "\nconst defaultConfiguration: Required<ConfigurationParameters> = {") || strings.Contains(got, "export const defaultConfiguration") {
+		t.Errorf("output does not contain an internal defaultConfiguration")
+	}
+	if !strings.Contains(got, "this.configuration = {...defaultConfiguration, ...configuration};") || strings.Contains(got, "|| 10000") {
+		t.Errorf("client does not use defaultConfiguration")
+	}
+}
+
+func TestEmitOtel(t *testing.T) {
+	input := filepath.Join("testdata", "no_content.swagger.json")
+	want := `return this.traced("Nakama_SessionLogout", fetchOptions, () => this.doFetch(fullUrl, fetchOptions, false, "void"));`
+	if got := generateOutput(t, "-emit-otel", input); !strings.Contains(got, want) {
+		t.Errorf("output does not trace the operation:\n%s", got)
+	}
+	if got := generateOutput(t, input); strings.Contains(got, "traced(") {
+		t.Errorf("output traces operations without -emit-otel")
+	}
+}
+
+func TestPruneUnused(t *testing.T) {
+	input := filepath.Join("testdata", "all_types.swagger.json")
+	output, err := runGenerator(t, "-prune-unused", "-verbose", input, "Nakama")
+	if err != nil {
+		t.Fatalf("generator failed: %s\n%s", err, output)
+	}
+	if strings.Contains(string(output), "ApiUserData") {
+		t.Error("output with --prune-unused contains the unused ApiUserData")
+	}
+	if !strings.Contains(string(output), "Pruned 2 unused definitions.") {
+		t.Error("--verbose does not log the number of pruned definitions")
+	}
+
+	if got := generateOutput(t, input, "Nakama"); !strings.Contains(got, "export interface ApiUserData {") {
+		t.Error("output without --prune-unused does not contain the unused ApiUserData")
+	}
+}
+
+// filterSpec has operations in two tags, whose definitions refer to others.
+const filterSpec = `{
+  "paths": {
+    "/v2/account": {
+      "get": {
+        "operationId": "Nakama_GetAccount",
+        "responses": {"200": {"schema": {"$ref": "#/definitions/apiAccount"}}},
+        "tags": ["Account"]
+      }
+    },
+    "/v2/leaderboard/{id}": {
+      "post": {
+        "operationId": "Nakama_WriteLeaderboardRecord",
+        "parameters": [{"name": "body", "in": "body", "schema": {"$ref": "#/components/schemas/apiWriteRecord"}}],
+        "responses": {"200": {"schema": {"$ref": "#/definitions/apiRecord"}}},
+        "tags": ["Leaderboard", "Social"]
+      }
+    },
+    "/v2/healthcheck": {
+      "get": {
+        "operationId": "Nakama_Healthcheck",
+        "responses": {"200": {"schema": {"type": "object"}}}
+      }
+    }
+  },
+  "definitions": {
+    "apiAccount": {"properties": {"user": {"$ref": "#/definitions/apiUser"}, "devices": {"type": "array", "items": {"$ref": "#/definitions/apiDevice"}}}},
+    "apiUser": {"properties": {"account": {"$ref": "#/definitions/apiAccount"}}},
+    "apiDevice": {"properties": {"id": {"type": "string"}}},
+    "apiRecord": {"properties": {"owner": {"$ref": "#/definitions/apiUser"}}},
+    "apiWriteRecord": {"properties": {"score": {"type": "string"}}},
+    "apiUnused": {"properties": {"id": {"type": "string"}}}
+  }
+}`
+
+// filterSchema decodes filterSpec.
+func filterSchema(t *testing.T) Schema {
+	t.Helper()
+	var schema Schema
+	if err := json.Unmarshal([]byte(filterSpec), &schema); err != nil {
+		t.Fatal(err)
+	}
+	return schema
+}
+
+// pathNames returns the sorted paths of a schema.
+func pathNames(schema Schema) []string {
+	var urls []string
+	for url := range schema.Paths {
+		urls = append(urls, url)
+	}
+	sort.Strings(urls)
+	return urls
+}
+
+// definitionNames returns the sorted names of the definitions of a schema.
+func definitionNames(schema Schema) []string {
+	var names []string
+	for name := range schema.Definitions {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestReachableDefinitions(t *testing.T) {
+	schema := filterSchema(t)
+	tests := []struct {
+		url  string
+		want map[string]bool
+	}{
+		// apiUser refers back to apiAccount, which is visited only once.
+		{"/v2/account", map[string]bool{"apiAccount": true, "apiUser": true, "apiDevice": true}},
+		{"/v2/leaderboard/{id}", map[string]bool{"apiWriteRecord": true, "apiRecord": true, "apiUser": true, "apiAccount": true, "apiDevice": true}},
+		{"/v2/healthcheck", map[string]bool{}},
+	}
+
+	for _, tt := range tests {
+		paths := map[string]map[string]Operation{tt.url: schema.Paths[tt.url]}
+		if got := reachableDefinitions(paths, schema.Definitions); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("reachableDefinitions(%q) = %v, want %v", tt.url, got, tt.want)
+		}
+	}
+}
+
+func TestFilterByTag(t *testing.T) {
+	tests := []struct {
+		tags        []string
+		paths       []string
+		definitions []string
+	}{
+		{[]string{"Account"}, []string{"/v2/account"}, []string{"apiAccount", "apiDevice", "apiUser"}},
+		// an operation is kept when any of its tags is given.
+		{[]string{"Social"}, []string{"/v2/leaderboard/{id}"}, []string{"apiAccount", "apiDevice", "apiRecord", "apiUser", "apiWriteRecord"}},
+		{[]string{"Account", "Leaderboard"}, []string{"/v2/account", "/v2/leaderboard/{id}"}, []string{"apiAccount", "apiDevice", "apiRecord", "apiUser", "apiWriteRecord"}},
+		{[]string{"Unknown"}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		schema := filterSchema(t)
+		filterByTag(&schema, tt.tags)
+		if paths := pathNames(schema); !reflect.DeepEqual(paths, tt.paths) {
+			t.Errorf("filterByTag(%v) kept paths %v, want %v", tt.tags, paths, tt.paths)
+		}
+		if got := definitionNames(schema); !reflect.DeepEqual(got, tt.definitions) {
+			t.Errorf("filterByTag(%v) kept definitions %v, want %v", tt.tags, got, tt.definitions)
+		}
+	}
+}
+
+func TestFilterByPathPrefix(t *testing.T) {
+	tests := []struct {
+		prefixes    []string
+		paths       []string
+		definitions []string
+	}{
+		{[]string{"/v2/account"}, []string{"/v2/account"}, []string{"apiAccount", "apiDevice", "apiUser"}},
+		// prefixes match any path starting with them, not only whole segments.
+		{[]string{"/v2/leader"}, []string{"/v2/leaderboard/{id}"}, []string{"apiAccount", "apiDevice", "apiRecord", "apiUser", "apiWriteRecord"}},
+		{[]string{"/v2/healthcheck", "/v2/account"}, []string{"/v2/account", "/v2/healthcheck"}, []string{"apiAccount", "apiDevice", "apiUser"}},
+		{[]string{"/v2"}, []string{"/v2/account", "/v2/healthcheck", "/v2/leaderboard/{id}"}, []string{"apiAccount", "apiDevice", "apiRecord", "apiUser", "apiWriteRecord"}},
+		{[]string{"/v3"}, nil, nil},
+	}
+
+	for _, tt := range tests {
+		schema := filterSchema(t)
+		filterByPathPrefix(&schema, tt.prefixes)
+		if paths := pathNames(schema); !reflect.DeepEqual(paths, tt.paths) {
+			t.Errorf("filterByPathPrefix(%v) kept paths %v, want %v", tt.prefixes, paths, tt.paths)
+		}
+		if got := definitionNames(schema); !reflect.DeepEqual(got, tt.definitions) {
+			t.Errorf("filterByPathPrefix(%v) kept definitions %v, want %v", tt.prefixes, got, tt.definitions)
+		}
+	}
+}
+
+func TestNoConstEnum(t *testing.T) {
+	input := filepath.Join("testdata", "all_types.swagger.json")
+	if got := generateOutput(t, "-no-const-enum", input, "Nakama"); !strings.Contains(got, "export enum Gender {\n  UNKNOWN = 0,") {
+		t.Error("output with --no-const-enum does not contain a plain Gender enum")
+	}
+	if got := generateOutput(t, input, "Nakama"); !strings.Contains(got, "export const enum Gender {") {
+		t.Error("output does not contain a const Gender enum")
+	}
+}
+
+func TestEmitPostman(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "collection.json")
+	generateOutput(t, "-emit-postman", path, filepath.Join("testdata", "api.swagger.json"), "Nakama")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var collection struct {
+		Item []struct {
+			Name    string
+			Request struct {
+				Method string
+				Header []struct{ Key, Value string }
+				Url    struct{ Raw string }
+				Body   struct{ Mode, Raw string }
+			}
+		}
+		Variable []struct{ Key string }
+	}
+	if err := json.Unmarshal(data, &collection); err != nil {
+		t.Fatalf("collection is not valid JSON: %s\n%s", err, data)
+	}
+
+	for _, item := range collection.Item {
+		if item.Name != "AuthenticateEmail" {
+			continue
+		}
+		if item.Request.Method != "POST" || item.Request.Url.Raw != "{{baseUrl}}/v2/account/authenticate/email" {
+			t.Errorf("unexpected request %s %s", item.Request.Method, item.Request.Url.Raw)
+		}
+		if item.Request.Header[0].Value != "Basic {{basicAuth}}" {
+			t.Errorf("Authorization header is %q, want a basic auth placeholder", item.Request.Header[0].Value)
+		}
+		if want := "{\n  \"email\": \"\",\n  \"password\": \"\",\n  \"vars\": {}\n}"; item.Request.Body.Raw != want {
+			t.Errorf("body is %q, want %q", item.Request.Body.Raw, want)
+		}
+	}
+
+	var keys []string
+	for _, variable := range collection.Variable {
+		keys = append(keys, variable.Key)
+	}
+	if want := []string{"baseUrl", "bearerToken", "basicAuth", "collection", "id", "leaderboardId", "stream"}; !reflect.DeepEqual(keys, want) {
+		t.Errorf("variables are %v, want %v", keys, want)
+	}
+}
+
+func TestEmitHttp(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "requests.http")
+	generateOutput(t, "-emit-http", path, filepath.Join("testdata", "api.swagger.json"), "Nakama")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"@baseUrl = http://127.0.0.1:7350\n",
+		"@leaderboardId = leaderboardId\n",
+		"### Tag: Leaderboard\n\n### DeleteLeaderboardRecord\n# Delete a leaderboard record.\nDELETE {{baseUrl}}/v2/leaderboard/{{leaderboardId}}\nAuthorization: Bearer {{bearerToken}}\n",
+		"POST {{baseUrl}}/v2/account/authenticate/email\nAuthorization: Basic {{basicAuth}}\nContent-Type: application/json\n\n{\n  \"email\": \"\",",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("HTTP file does not contain %q", want)
+		}
+	}
+}
+
+func TestEmitBruno(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "bruno")
+	generateOutput(t, "-emit-bruno", dir, filepath.Join("testdata", "api.swagger.json"), "Nakama")
+
+	for _, name := range []string{"bruno.json", filepath.Join("environments", "local.bru"), filepath.Join("nakama", "GetAccount.bru")} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("collection does not contain %s: %s", name, err)
+		}
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "leaderboard", "ListLeaderboardRecords.bru"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"meta {\n  name: ListLeaderboardRecords\n  type: http\n  seq: 2\n}\n",
+		"get {\n  url: {{baseUrl}}/v2/leaderboard/{{leaderboardId}}\n  body: none\n  auth: none\n}\n",
+		"params:query {\n  ~ownerIds:\n",
+		"headers {\n  Authorization: Bearer {{bearerToken}}\n}\n",
+		"vars:pre-request {\n  leaderboardId: leaderboardId\n}\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("request file does not contain %q", want)
+		}
+	}
+}
+
+func TestEmitDocs(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "api-docs.md")
+	generateOutput(t, "-emit-docs", path, filepath.Join("testdata", "api.swagger.json"), "Nakama")
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"## Authentication\n\n| Method | Path | Description | Parameters | Response |\n| --- | --- | --- | --- | --- |\n" +
+			"| `POST` | `/v2/account/authenticate/email` | Authenticate a user with an email+password. | `account`, `create`, `username` | [ApiSession](#apisession) |\n",
+		"| `create` | `boolean` | no | Register the account if the user does not already exist. |\n",
+		"| `DELETE` | `/v2/leaderboard/{leaderboardId}` | Delete a leaderboard record. | `leaderboardId` | `any` |\n",
+		"## Definitions\n",
+		"### ApiSession\n\nA user's session.\n",
+		"| `devices` | array of [ApiAccountDevice](#apiaccountdevice) | The devices which belong to the user's account. |\n",
+	} {
+		if !strings.Contains(string(data), want) {
+			t.Errorf("Markdown reference does not contain %q", want)
+		}
+	}
+}
+
+func TestEmitIndex(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.Mkdir(filepath.Join(dir, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	// a hand-written index.ts next to the output is left alone.
+	handWritten := filepath.Join(dir, "src", "index.ts")
+	if err := os.WriteFile(handWritten, []byte("export * from './client';\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	output, err := runGenerator(t, "-emit-index", filepath.Join(dir, "index.ts"), "-output", filepath.Join(dir, "src", "api.gen.ts"), filepath.Join("testdata", "path_parameters.swagger.json"), "Nakama")
+	if err != nil {
+		t.Fatalf("generator failed: %s\n%s", err, output)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "index.ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, string(got), "path_parameters.index.ts.golden")
+	if content, err := os.ReadFile(handWritten); err != nil || string(content) != "export * from './client';\n" {
+		t.Errorf("the index.ts next to the output was overwritten: %q", content)
+	}
+}
+
+func TestEmitCloudRun(t *testing.T) {
+	dir := t.TempDir()
+	output, err := runGenerator(t, "-emit-cloud-run", "-output", filepath.Join(dir, "api.gen.ts"), filepath.Join("testdata", "cloud_run.swagger.json"), "Nakama")
+	if err != nil {
+		t.Fatalf("generator failed: %s\n%s", err, output)
+	}
+
+	// operations with required parameters cannot be scheduled, so the golden has no finalizeTournament.
+	got, err := os.ReadFile(filepath.Join(dir, "main.ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, string(got), "cloud_run.main.ts.golden")
+}
+
+func TestEmitServiceWorkerCache(t *testing.T) {
+	dir := t.TempDir()
+	output, err := runGenerator(t, "-emit-service-worker-cache", "-output", filepath.Join(dir, "api.gen.ts"), filepath.Join("testdata", "cache_strategy.swagger.json"), "Nakama")
+	if err != nil {
+		t.Fatalf("generator failed: %s\n%s", err, output)
+	}
+
+	got, err := os.ReadFile(filepath.Join(dir, "nakama-sw.ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	assertGolden(t, string(got), "cache_strategy.nakama-sw.ts.golden")
+}
+
+func TestPathPattern(t *testing.T) {
+	tests := []struct {
+		url   string
+		path  string
+		match bool
+	}{
+		{"/v2/account", "/v2/account", true},
+		{"/v2/account", "/v2/account/link", false},
+		{"/v2/user/{id}", "/v2/user/abc", true},
+		{"/v2/user/{id}", "/v2/user/", false},
+		{"/v2/user/{id}", "/v2/user/abc/friends", false},
+		// requests to a basePath with a path of its own.
+		{"/v2/user/{id}", "/nakama/v2/user/abc", true},
+		{"/v2/rpc/{id}", "/v2/rpc.x/abc", false},
+	}
+
+	for _, tt := range tests {
+		// the JavaScript pattern is also valid Go syntax.
+		pattern := regexp.MustCompile(pathPattern(tt.url) + "$")
+		if got := pattern.MatchString(tt.path); got != tt.match {
+			t.Errorf("pattern of %q matches %q: %v, want %v", tt.url, tt.path, got, tt.match)
+		}
+	}
+}
+
+func TestEmitPackageJson(t *testing.T) {
+	dir := t.TempDir()
+	// the package lives above the output directory, which holds hand-written code.
+	if err := os.Mkdir(filepath.Join(dir, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	output, err := runGenerator(t, "-emit-package-json", filepath.Join(dir, "package.json"), "-emit-react-hooks", "-prefix", "Nk", "-output", filepath.Join(dir, "src", "api.gen.ts"), filepath.Join("testdata", "api.swagger.json"), "Nakama")
+	if err != nil {
+		t.Fatalf("generator failed: %s\n%s", err, output)
+	}
+
+	// the package does not ship the utils module, so the client must not import it.
+	code, err := os.ReadFile(filepath.Join(dir, "src", "api.gen.ts"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(code), "./utils") || !strings.Contains(string(code), "export function buildFetchOptions(") {
+		t.Error("packaged client does not define buildFetchOptions itself")
+	}
+
+	data, err := os.ReadFile(filepath.Join(dir, "package.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var pkg PackageJson
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		t.Fatalf("package.json is not valid JSON: %s\n%s", err, data)
+	}
+	if pkg.Name != "nk-nakama" || pkg.Version != "2.0.0" {
+		t.Errorf("package is %s@%s, want nk-nakama@2.0.0", pkg.Name, pkg.Version)
+	}
+	if want := (PackageExport{Types: "./dist/api.gen.d.ts", Import: "./dist/api.gen.js"}); pkg.Exports["."] != want {
+		t.Errorf("exports are %+v, want %+v", pkg.Exports["."], want)
+	}
+	if got, want := pkg.Scripts["build"], "tsc --declaration --module es2020 --outDir dist src/api.gen.ts"; got != want {
+		t.Errorf("build script is %q, want %q", got, want)
+	}
+	if pkg.PeerDependencies["react"] == "" {
+		t.Error("React hooks do not add a peer dependency on react")
+	}
+	if strings.Contains(string(data), "\\u003e") {
+		t.Error("package.json escapes > in version ranges")
+	}
+
+	// a relative package.json path and an absolute output still give a relative build script.
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	pkg, err = newPackageJson("package.json", Config{ModuleFormat: "esm", Output: filepath.Join(wd, "src", "api.gen.ts")}, Schema{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := pkg.Scripts["build"], "tsc --declaration --module es2020 --outDir dist src/api.gen.ts"; got != want {
+		t.Errorf("build script is %q, want %q", got, want)
+	}
+}
+
+func TestPackageVersion(t *testing.T) {
+	for version, want := range map[string]string{"": "0.0.0", "2": "2.0.0", "2.0": "2.0.0", "2.5.3": "2.5.3"} {
+		if got := packageVersion(version); got != want {
+			t.Errorf("packageVersion(%q) = %q, want %q", version, got, want)
+		}
+	}
+}
+
+func TestEmitTsconfig(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "tsconfig.json")
+	if err := os.Mkdir(filepath.Join(dir, "src"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	generated := filepath.Join(dir, "src", "api.gen.ts")
+	output, err := runGenerator(t, "-emit-tsconfig", path, "-strict", "-emit-zod", "-output", generated, filepath.Join("testdata", "api.swagger.json"), "Nakama")
+	if err != nil {
+		t.Fatalf("generator failed: %s\n%s", err, output)
+	}
+	if !strings.Contains(string(output), path+"\n") {
+		t.Errorf("output does not contain the tsconfig.json path:\n%s", output)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Tsconfig
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatalf("tsconfig.json is not valid JSON: %s\n%s", err, data)
+	}
+	if want, err := newTsconfig(path, Config{ModuleFormat: "esm", Strict: true, EmitZod: true, Output: generated}); err != nil || !reflect.DeepEqual(got, want) {
+		t.Errorf("unexpected tsconfig.json:\n%s", data)
+	}
+	if strings.Contains(string(data), `"types"`) {
+		t.Errorf("tsconfig.json restricts the included @types packages:\n%s", data)
+	}
+	if !reflect.DeepEqual(got.Include, []string{"src/api.gen.ts"}) {
+		t.Errorf("tsconfig.json includes %v, want the output relative to it", got.Include)
+	}
+
+	// a package above the tsconfig.json builds with it and ships its dist.
+	pkg, err := newPackageJson("package.json", Config{ModuleFormat: "esm", Output: "src/api.gen.ts", EmitTsconfig: "src/tsconfig.json"}, Schema{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pkg.Scripts["build"] != "tsc -p src/tsconfig.json" || pkg.Main != "src/dist/api.gen.js" {
+		t.Errorf("package.json builds with %q into %q", pkg.Scripts["build"], pkg.Main)
+	}
+
+	defaults, err := newTsconfig(path, Config{ModuleFormat: "esm", Output: generated})
+	if err != nil {
+		t.Fatal(err)
+	}
+	options := defaults.CompilerOptions
+	if !options.Strict || options.StrictNullChecks || options.Module != "ESNext" || options.ModuleResolution != "bundler" {
+		t.Errorf("unexpected default compiler options %+v", options)
+	}
+	if data, _ := json.Marshal(defaults); !strings.Contains(string(data), `"strictNullChecks":false`) {
+		t.Errorf("default tsconfig.json leaves strictNullChecks to strict:\n%s", data)
+	}
+}
+
+func TestModuleFormat(t *testing.T) {
+	input := filepath.Join("testdata", "api.swagger.json")
+	esm := generateOutput(t, input, "Nakama")
+	for format, module := range map[string]string{"cjs": "CommonJS", "umd": "UMD"} {
+		// the generated code keeps its ES module syntax, which tsc compiles into the module format.
+		tsconfigPath := filepath.Join(t.TempDir(), "tsconfig.json")
+		if got := generateOutput(t, "-module-format", format, "-emit-tsconfig", tsconfigPath, input, "Nakama"); got != esm {
+			t.Errorf("%s output differs from the esm output", format)
+		}
+		tsconfig, err := newTsconfig("tsconfig.json", Config{ModuleFormat: format, Output: "api.gen.ts"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := tsconfig.CompilerOptions.Module; got != module {
+			t.Errorf("%s tsconfig.json module = %q, want %q", format, got, module)
+		}
+		pkg, err := newPackageJson("package.json", Config{ModuleFormat: format, Namespace: "Nakama", Output: "api.gen.ts"}, Schema{})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if pkg.Type != "commonjs" || !strings.Contains(pkg.Scripts["build"], "--module "+strings.ToLower(module)) {
+			t.Errorf("%s package.json has type %q and build script %q", format, pkg.Type, pkg.Scripts["build"])
+		}
+	}
+}
+
+func TestSkipUnchanged(t *testing.T) {
+	dir := t.TempDir()
+	output := filepath.Join(dir, "api.gen.ts")
+	input := filepath.Join("testdata", "api.swagger.json")
+	run := func(args ...string) string {
+		t.Helper()
+		out, err := runGenerator(t, append([]string{"-skip-unchanged", "-output", output}, append(args, input, "Nakama")...)...)
+		if err != nil {
+			t.Fatalf("generator failed: %s\n%s", err, out)
+		}
+		return string(out)
+	}
+
+	if got := run(); strings.Contains(got, "unchanged") {
+		t.Errorf("first run skipped the output: %s", got)
+	}
+	hashes := readHashes(filepath.Join(dir, hashFile))
+	if len(hashes["api.gen.ts"]) != 64 {
+		t.Fatalf("hash file has no SHA-256 hash of the output: %v", hashes)
+	}
+
+	// A modified output is not overwritten while the hash matches.
+	if err := os.WriteFile(output, []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if got := run(); got != output+" unchanged, skipping\n" {
+		t.Errorf("second run printed %q", got)
+	}
+	if content, _ := os.ReadFile(output); string(content) != "edited" {
+		t.Error("unchanged output was rewritten")
+	}
+
+	if got := run("-strict"); strings.Contains(got, "unchanged") {
+		t.Errorf("run with another option skipped the output: %s", got)
+	}
+	if content, _ := os.ReadFile(output); string(content) == "edited" {
+		t.Error("changed output was not written")
+	}
+
+	// A deleted side file is regenerated although the output is unchanged.
+	index := filepath.Join(dir, "index.ts")
+	run("-emit-index", index)
+	if err := os.Remove(index); err != nil {
+		t.Fatal(err)
+	}
+	if got := run("-emit-index", index); strings.Contains(got, "unchanged") {
+		t.Errorf("run with a missing index skipped the output: %s", got)
+	}
+	if _, err := os.Stat(index); err != nil {
+		t.Errorf("missing index was not regenerated: %s", err)
+	}
+}
+
+func TestOutputHash(t *testing.T) {
+	code := []byte("export class NakamaApi {}")
+	base := Config{Input: "api.swagger.json", Output: "api.gen.ts", Workers: 4, ModuleFormat: "esm"}
+	want, err := outputHash(base, code)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	same := base
+	same.Input, same.Workers, same.Verbose, same.Quiet = "-", 1, true, true
+	same.FetchTimeout, same.Insecure = time.Minute, true
+	if got, _ := outputHash(same, code); got != want {
+		t.Error("options which do not affect the output changed the hash")
+	}
+	changed := base
+	changed.EmitIndex = "index.ts"
+	if got, _ := outputHash(changed, code); got == want {
+		t.Error("an option which affects the written files did not change the hash")
+	}
+}
+
+func TestSkipUnchangedSplitByTag(t *testing.T) {
+	dir := t.TempDir()
+	run := func() string {
+		t.Helper()
+		out, err := runGenerator(t, "-skip-unchanged", "-split-by-tag", "-output-dir", dir, filepath.Join("testdata", "tags.swagger.json"), "Nakama")
+		if err != nil {
+			t.Fatalf("generator failed: %s\n%s", err, out)
+		}
+		return string(out)
+	}
+
+	if got := run(); strings.Contains(got, "unchanged") {
+		t.Errorf("first run skipped files: %s", got)
+	}
+	hashes := readHashes(filepath.Join(dir, hashFile))
+	if len(hashes) != 5 || len(hashes["account.ts"]) != 64 {
+		t.Fatalf("hash file does not hash each of the 5 files: %v", hashes)
+	}
+
+	// Only the edited file is kept, a deleted one is written again.
+	account, leaderboard := filepath.Join(dir, "account.ts"), filepath.Join(dir, "leaderboard.ts")
+	if err := os.WriteFile(account, []byte("edited"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(leaderboard); err != nil {
+		t.Fatal(err)
+	}
+	got := run()
+	if !strings.Contains(got, account+" unchanged, skipping\n") || strings.Contains(got, leaderboard+" unchanged") {
+		t.Errorf("second run printed %q", got)
+	}
+	if content, _ := os.ReadFile(account); string(content) != "edited" {
+		t.Error("unchanged file was rewritten")
+	}
+	if _, err := os.Stat(leaderboard); err != nil {
+		t.Errorf("deleted file was not written again: %s", err)
+	}
+}
+
+func TestGenerateFromStdin(t *testing.T) {
+	input := filepath.Join("testdata", "api.swagger.json")
+	content, err := os.ReadFile(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reader, writer := io.Pipe()
+	stdin = reader
+	t.Cleanup(func() { stdin = os.Stdin })
+	go func() {
+		writer.Write(content)
+		writer.Close()
+	}()
+
+	if got, want := generateOutput(t, "-", "Nakama"), generateOutput(t, input, "Nakama"); got != want {
+		t.Error("output generated from stdin differs from the output generated from the file")
+	}
+}
+
+func TestLogLevels(t *testing.T) {
+	dir := t.TempDir()
+	input := filepath.Join("testdata", "api.swagger.json")
+	run := func(args ...string) (string, string) {
+		t.Helper()
+		var stdout, stderr strings.Builder
+		cmd := generatorCommand(t, append(args, input, "Nakama")...)
+		cmd.Stdout, cmd.Stderr = &stdout, &stderr
+		if err := cmd.Run(); err != nil {
+			t.Fatalf("generator failed: %s\n%s", err, stderr.String())
+		}
+		return stdout.String(), stderr.String()
+	}
+
+	stdout, stderr := run("-verbose", "-output", filepath.Join(dir, "api.gen.ts"))
+	if stdout != "" {
+		t.Errorf("--verbose with --output printed to stdout:\n%s", stdout)
+	}
+	info, err := os.Stat(input)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{fmt.Sprintf("Read %d bytes from %s.\n", info.Size(), input), "Rendering 10 definitions and 10 operations.\n", "Rendered " + filepath.Join(dir, "api.gen.ts") + " in "} {
+		if !strings.Contains(stderr, want) {
+			t.Errorf("--verbose did not log %q:\n%s", want, stderr)
+		}
+	}
+
+	stdout, stderr = run("-quiet", "-emit-tsconfig", filepath.Join(dir, "tsconfig.json"), "-output", filepath.Join(dir, "api.gen.ts"))
+	if stdout != "" || stderr != "" {
+		t.Errorf("--quiet printed %q to stdout and %q to stderr", stdout, stderr)
+	}
+
+	stdout, stderr = run("-verbose")
+	if !strings.HasPrefix(stdout, "// tslint:disable") || strings.Contains(stdout, "Rendered") {
+		t.Errorf("--verbose mixed messages into the generated code on stdout:\n%s", stdout)
+	}
+	if !strings.Contains(stderr, "Rendered stdout in ") {
+		t.Errorf("--verbose did not log the render time:\n%s", stderr)
+	}
+}
+
+func TestNoBanner(t *testing.T) {
+	input := filepath.Join("testdata", "api.swagger.json")
+	got := generateOutput(t, "-no-banner", input, "Nakama")
+	if strings.Contains(got, "DO NOT EDIT") || strings.Contains(got, "tslint:disable") {
+		t.Error("output with --no-banner contains the banner")
+	}
+	if strings.HasPrefix(got, "\n") {
+		t.Error("output with --no-banner starts with an empty line")
+	}
+
+	want := generateOutput(t, input, "Nakama")
+	banner := "// tslint:disable\n/* Code generated by openapi-gen/main.go. DO NOT EDIT. */\n/* Generated from api.proto version 2.0. */\n\n"
+	if want != banner+got {
+		t.Error("output with --no-banner differs from the output without the banner")
+	}
+}
+
+func TestEmitRequestTypes(t *testing.T) {
+	got := generateOutput(t, "-emit-request-types", "-emit-react-hooks", filepath.Join("testdata", "api.swagger.json"), "Nakama")
+	for _, want := range []string{
+		"export interface AuthenticateEmailRequest {\n  // The email account details.\n  account: ApiAccountEmail;\n",
+		"      request: AuthenticateEmailRequest,\n      options: any = {}): Promise<ApiSession> {\n    const { account, create, username } = request;\n",
+		"   * @param {boolean} request.create - ",
+		"export function useAuthenticateEmail(api: NakamaApi, basicAuthUsername: string, basicAuthPassword: string, request: AuthenticateEmailRequest, options: any = {}) {",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output with --emit-request-types does not contain %q", want)
+		}
+	}
+	if strings.Contains(got, "interface GetAccountRequest") {
+		t.Errorf("output with --emit-request-types contains a request interface for an operation without parameters")
+	}
+}
+
+func TestEmitResponseTypes(t *testing.T) {
+	got := generateOutput(t, "-emit-response-types", "-emit-rxjs", filepath.Join("testdata", "api.swagger.json"), "Nakama")
+	for _, want := range []string{
+		"export interface AuthenticateEmailResponse {\n  data: ApiSession;\n  status: number;\n  headers: Headers;\n}\n",
+		"options: any = {}): Promise<AuthenticateEmailResponse> {",
+		"const wrap = (data: any) => ({data: data, status: response.status, headers: response.headers});",
+		"Observable<AuthenticateEmailResponse>",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output with --emit-response-types does not contain %q", want)
+		}
+	}
+
+	if got := generateOutput(t, filepath.Join("testdata", "api.swagger.json"), "Nakama"); strings.Contains(got, "AuthenticateEmailResponse") {
+		t.Errorf("output without --emit-response-types contains a response interface")
+	}
+}
+
+func TestGroupByTag(t *testing.T) {
+	got := generateOutput(t, "-group-by-tag", filepath.Join("testdata", "api.swagger.json"), "Nakama")
+	want := "  // The operations whose first tag is Authentication.\n  readonly authentication = {\n    authenticateEmail: this.authenticateEmail.bind(this),\n"
+	if !strings.Contains(got, want) {
+		t.Errorf("output with --group-by-tag does not contain %q", want)
+	}
+	if !strings.Contains(got, "  authenticateEmail(basicAuthUsername: string,") {
+		t.Errorf("output with --group-by-tag does not keep the method on the class")
+	}
+}
+
+func TestCheckTagProperties(t *testing.T) {
+	tests := []struct {
+		tags    []string
+		members string
+	}{
+		{[]string{"Account", "Leaderboard"}, ""},
+		{[]string{"Circuit"}, "a member of the API class"},
+		{[]string{"Configuration"}, "a member of the API class"},
+		{[]string{"Inflight"}, "a member of the API class"},
+		{[]string{"Op0"}, "the method of Nakama_Op0"},
+		{[]string{"Leaderboard Records", "leaderboard_records"}, "the object of tag"},
+	}
+	for _, tt := range tests {
+		paths := make(map[string]map[string]Operation)
+		for i, tag := range tt.tags {
+			paths[fmt.Sprintf("/v2/op%d", i)] = map[string]Operation{"get": {OperationId: fmt.Sprintf("Nakama_Op%d", i), Tags: []string{tag}}}
+		}
+		err := checkTagProperties(paths)
+		if tt.members == "" && err != nil {
+			t.Errorf("tags %q: %s", tt.tags, err)
+		}
+		if tt.members != "" && (err == nil || !strings.Contains(err.Error(), tt.members)) {
+			t.Errorf("tags %q are accepted with error %v, want a collision with %s", tt.tags, err, tt.members)
+		}
+	}
+}
+
+func TestEmitMock(t *testing.T) {
+	got := generateOutput(t, "-emit-mock", filepath.Join("testdata", "api.swagger.json"), "Nakama")
+	for _, want := range []string{
+		// every call is recorded in mock.calls before the method resolves.
+		"    calls.push(args);\n    return Promise.resolve(value);\n",
+		"  return Object.assign(method, { mock: { calls } }) as unknown as MockMethod<F>;\n",
+		"export function createMockNakamaApi(defaults: { [K in keyof NakamaApi]?: any } = {}) {",
+		"    getAccount: mockMethod<NakamaApi[\"getAccount\"]>(\"getAccount\" in defaults ? defaults.getAccount : {}),\n",
+		"    rpcFunc: mockMethod<NakamaApi[\"rpcFunc\"]>(\"rpcFunc\" in defaults ? defaults.rpcFunc : {}),\n",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output with --emit-mock does not contain %q", want)
+		}
+	}
+	// event streams return an EventStream rather than a promise.
+	if strings.Contains(got, "streamEvents: mockMethod") {
+		t.Error("output with --emit-mock mocks an event stream")
+	}
+	if got := generateOutput(t, filepath.Join("testdata", "api.swagger.json"), "Nakama"); strings.Contains(got, "createMockNakamaApi") {
+		t.Error("output without --emit-mock contains createMockNakamaApi")
+	}
+}
+
+func TestHealthOperation(t *testing.T) {
+	healthcheck := Operation{OperationId: "Nakama_Healthcheck"}
+	ping := Operation{OperationId: "Nakama_Ping", XNakamaHealth: true}
+	tests := []struct {
+		paths map[string]map[string]Operation
+		want  string
+	}{
+		{map[string]map[string]Operation{"/healthcheck": {"get": healthcheck}}, "Nakama_Healthcheck"},
+		{map[string]map[string]Operation{"/healthcheck": {"get": healthcheck}, "/v2/ping": {"get": ping}}, "Nakama_Ping"},
+		{map[string]map[string]Operation{"/v2/account": {"get": {OperationId: "Nakama_GetAccount"}}}, ""},
+	}
+	for _, tt := range tests {
+		if got, _ := healthOperation(tt.paths); got.OperationId != tt.want {
+			t.Errorf("healthOperation(%v) = %q, want %q", tt.paths, got.OperationId, tt.want)
+		}
+	}
+}
+
+func TestEnumMemberNames(t *testing.T) {
+	values := []json.RawMessage{json.RawMessage("-1"), json.RawMessage("0"), json.RawMessage("1")}
+	tests := []struct {
+		property Property
+		want     []string
+	}{
+		{Property{Enum: values, XEnumNames: []string{"NONE", "UNKNOWN", "MALE"}, XEnumVarnames: []string{"None", "Unknown", "Male"}}, []string{"None", "Unknown", "Male"}},
+		{Property{Enum: values, XEnumNames: []string{"NONE", "UNKNOWN", "MALE"}}, []string{"NONE", "UNKNOWN", "MALE"}},
+		{Property{Enum: values, XEnumNames: []string{"NONE", "UNKNOWN", "MALE"}, XEnumVarnames: []string{"None"}}, []string{"NONE", "UNKNOWN", "MALE"}},
+	}
+	for _, tt := range tests {
+		if got := enumMemberNames(tt.property); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("enumMemberNames(%+v) = %v, want %v", tt.property, got, tt.want)
+		}
+	}
+}
+
+func TestIntegerEnums(t *testing.T) {
+	values := []json.RawMessage{json.RawMessage("0"), json.RawMessage("1")}
+	status := Property{Type: "integer", Enum: values, XEnumNames: []string{"OPEN", "CLOSED"}}
+	other := Property{Type: "integer", Enum: values, XEnumNames: []string{"ACTIVE", "BANNED"}}
+	unnamed := Property{Type: "integer", Enum: values}
+
+	tests := []struct {
+		definitions map[string]Definition
+		want        []IntegerEnum
+		err         string
+	}{
+		// without x-enum-names an integer enum stays a number.
+		{map[string]Definition{"apiGroup": {Properties: map[string]Property{"state": unnamed}}}, []IntegerEnum{}, ""},
+		{map[string]Definition{
+			"apiGroup":  {Properties: map[string]Property{"status": status}},
+			"apiMember": {Properties: map[string]Property{"status": status}},
+		}, []IntegerEnum{{Name: "Status", Names: []string{"OPEN", "CLOSED"}, Values: []string{"0", "1"}}}, ""},
+		{map[string]Definition{
+			"apiGroup":  {Properties: map[string]Property{"status": status}},
+			"apiMember": {Properties: map[string]Property{"status": other}},
+		}, nil, "Enum Status of apiMember.status differs from the one of apiGroup.status."},
+		{map[string]Definition{
+			"apiGroup": {Properties: map[string]Property{"status": status}},
+			"status":   {Properties: map[string]Property{"id": {Type: "string"}}},
+		}, nil, "Enum Status of apiGroup.status would have the name of definition status."},
+	}
+
+	for _, tt := range tests {
+		// conflicts are reported the same way whatever the map order.
+		for i := 0; i < 10; i++ {
+			got, err := integerEnums(tt.definitions)
+			if tt.err != "" {
+				if err == nil || err.Error() != tt.err {
+					t.Errorf("integerEnums() error = %v, want %q", err, tt.err)
+				}
+			} else if err != nil || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("integerEnums() = %+v, %v, want %+v", got, err, tt.want)
+			}
+		}
+	}
+}
+
+func TestNewConfig(t *testing.T) {
+	fs := flag.NewFlagSet("openapi-gen", flag.ContinueOnError)
+	cfg, err := newConfig(fs, []string{"-tag", "Nakama", "-tag", "Storage", "-emit-zod", "-indent", "tab", "api.swagger.json", "Satori"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Input != "api.swagger.json" || cfg.Namespace != "Satori" {
+		t.Errorf("input and namespace = %q, %q", cfg.Input, cfg.Namespace)
+	}
+	if !reflect.DeepEqual(cfg.Tags, []string{"Nakama", "Storage"}) || !cfg.EmitZod || cfg.Indent != "tab" {
+		t.Errorf("flags were not parsed: %+v", cfg)
+	}
+	if cfg.ModuleFormat != "esm" || cfg.ConflictStrategy != "error" {
+		t.Errorf("defaults were not set: %+v", cfg)
+	}
+}
+
+func TestConfigFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "config.json")
+	content := `{
+		"args": ["apigrpc.swagger.json", "Game"],
+		"emit-zod": true,
+		"module-format": "cjs",
+		"namespace": "GameClient",
+		"fetch-timeout": "30s",
+		"workers": 2,
+		"tag": ["Nakama"],
+		"merge": ["extra.json", "more.json"]
+	}`
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	fs := flag.NewFlagSet("openapi-gen", flag.ContinueOnError)
+	cfg, err := newConfig(fs, []string{"-config", path, "-module-format", "esm", "-tag", "Storage", "api.swagger.json"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !cfg.EmitZod || cfg.TsNamespace != "GameClient" || cfg.FetchTimeout != 30*time.Second || cfg.Workers != 2 || !reflect.DeepEqual(cfg.Merge, []string{"extra.json", "more.json"}) {
+		t.Errorf("options of the config file were not read as flags: %+v", cfg)
+	}
+	if cfg.ModuleFormat != "esm" || !reflect.DeepEqual(cfg.Tags, []string{"Storage"}) {
+		t.Errorf("flags do not override the config file: %+v", cfg)
+	}
+	if cfg.Input != "api.swagger.json" || cfg.Namespace != "" {
+		t.Errorf("input and namespace = %q and %q, want the command line argument alone", cfg.Input, cfg.Namespace)
+	}
+
+	fs = flag.NewFlagSet("openapi-gen", flag.ContinueOnError)
+	if cfg, err = newConfig(fs, []string{"-config", path}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Input != "apigrpc.swagger.json" || cfg.Namespace != "Game" {
+		t.Errorf("input and namespace = %q and %q, want the arguments of the config file", cfg.Input, cfg.Namespace)
+	}
+
+	for _, content := range []string{`{"emitZod": true}`, `{"workers": "many"}`} {
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		fs = flag.NewFlagSet("openapi-gen", flag.ContinueOnError)
+		if _, err := newConfig(fs, []string{"-config", path}); err == nil {
+			t.Errorf("config %s was accepted", content)
+		}
+	}
+}
+
+func TestConfigValidate(t *testing.T) {
+	valid := Config{Input: "api.swagger.json", ModuleFormat: "esm", ConflictStrategy: "error"}
+	if err := valid.Validate(); err != nil {
+		t.Fatalf("Validate() = %v", err)
+	}
+
+	tests := []struct {
+		name   string
+		modify func(cfg *Config)
+	}{
+		{"no input", func(cfg *Config) { cfg.Input = "" }},
+		{"empty namespace", func(cfg *Config) { cfg.namespaceGiven = true }},
+		{"unknown conflict strategy", func(cfg *Config) { cfg.ConflictStrategy = "merge" }},
+		{"changelog without compare", func(cfg *Config) { cfg.ChangelogOut = "CHANGELOG.md" }},
+		{"unknown module format", func(cfg *Config) { cfg.ModuleFormat = "amd" }},
+		{"validate responses without zod", func(cfg *Config) { cfg.ValidateResponses = true }},
+		{"react hooks and vue composables", func(cfg *Config) { cfg.EmitReactHooks = true; cfg.EmitVueComposables = true }},
+		{"split by tag without output directory", func(cfg *Config) { cfg.SplitByTag = true }},
+		{"verbose and quiet", func(cfg *Config) { cfg.Verbose = true; cfg.Quiet = true }},
+		{"split by tag without workers", func(cfg *Config) { cfg.SplitByTag = true; cfg.OutputDir = "api" }},
+		{"split by tag and group by tag", func(cfg *Config) {
+			cfg.SplitByTag = true
+			cfg.OutputDir = "api"
+			cfg.Workers = 1
+			cfg.GroupByTag = true
+		}},
+		{"index without output", func(cfg *Config) { cfg.EmitIndex = "index.ts" }},
+		{"index with split by tag", func(cfg *Config) { cfg.SplitByTag = true; cfg.OutputDir = "api"; cfg.EmitIndex = "index.ts" }},
+		{"index written to the output", func(cfg *Config) { cfg.Output = "api.gen.ts"; cfg.EmitIndex = "./api.gen.ts" }},
+		{"module format without tsconfig or package", func(cfg *Config) { cfg.ModuleFormat = "cjs" }},
+		{"cloud run with split by tag", func(cfg *Config) {
+			cfg.SplitByTag = true
+			cfg.OutputDir = "api"
+			cfg.Workers = 1
+			cfg.EmitCloudRun = true
+		}},
+		{"service worker with split by tag", func(cfg *Config) {
+			cfg.SplitByTag = true
+			cfg.OutputDir = "api"
+			cfg.Workers = 1
+			cfg.EmitServiceWorkerCache = true
+		}},
+	}
+	for _, tt := range tests {
+		cfg := valid
+		tt.modify(&cfg)
+		if err := cfg.Validate(); err == nil {
+			t.Errorf("%s: Validate() succeeded", tt.name)
+		}
+	}
+}
+
+func TestGenerateCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	output := filepath.Join(t.TempDir(), "api.gen.ts")
+	err := generate(ctx, Config{
+		Input:            filepath.Join("testdata", "api.swagger.json"),
+		Namespace:        "Nakama",
+		Output:           output,
+		Indent:           "  ",
+		ModuleFormat:     "esm",
+		ConflictStrategy: "error",
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("generate() = %v, want %v", err, context.Canceled)
+	}
+	if _, err := os.Stat(output); !os.IsNotExist(err) {
+		t.Errorf("partial output was not removed: %v", err)
+	}
+}
+
+func TestDeterministicOutput(t *testing.T) {
+	input := filepath.Join("testdata", "api.swagger.json")
+	want := generateOutput(t, input, "Nakama")
+	for i := 0; i < 5; i++ {
+		if got := generateOutput(t, input, "Nakama"); got != want {
+			t.Fatalf("run %d produced different output", i+2)
+		}
+	}
+}
+
+func TestZodType(t *testing.T) {
+	tests := []struct {
+		property Property
+		want     string
+	}{
+		{Property{Type: "string"}, "z.string()"},
+		{Property{Type: "string", Format: "date-time"}, "z.coerce.date()"},
+		{Property{Type: "string", Format: "byte"}, "z.string().transform(base64ToUint8Array)"},
+		{Property{Type: "integer", Format: "int32"}, "z.number()"},
+		{Property{Type: "integer", Format: "int64"}, "z.coerce.bigint()"},
+		{Property{Type: "boolean"}, "z.boolean()"},
+		{Property{Ref: "#/definitions/apiUser"}, "z.lazy(() => ApiUserSchema)"},
+	}
+
+	for _, tt := range tests {
+		if got := zodType(tt.property, "", true, true); got != tt.want {
+			t.Errorf("zodType(%+v) = %q, want %q", tt.property, got, tt.want)
+		}
+	}
+
+	// dates stay strings without --date-reviver.
+	if got := zodType(Property{Type: "string", Format: "date-time"}, "", false, false); got != "z.string()" {
+		t.Errorf("zodType() without dates = %q, want %q", got, "z.string()")
+	}
+	// int64 integers stay numbers with --no-bigint.
+	if got := zodType(Property{Type: "integer", Format: "int64"}, "", false, false); got != "z.number()" {
+		t.Errorf("zodType() without bigints = %q, want %q", got, "z.number()")
+	}
+}
+
+func TestJsonConversions(t *testing.T) {
+	definitions := map[string]Definition{
+		"apiAccount": {Properties: map[string]Property{
+			"user":       {Ref: "#/definitions/apiUser"},
+			"wallet":     {Type: "string"},
+			"createTime": {Type: "string", Format: "date-time", XTsType: "Timestamp"},
+			"version":    {Type: "integer", Format: "int64"},
+			"avatar":     {Type: "string", Format: "byte"},
+		}},
+		"apiUser": {Properties: map[string]Property{
+			"createTime": {Type: "string", Format: "date-time"},
+			"friends": {Type: "array", Items: struct {
+				Type string
+				Ref  string `json:"$ref"`
+			}{Ref: "#/definitions/apiUser"}},
+		}},
+		"apiEmpty": {Properties: map[string]Property{"account": {Ref: "#/definitions/apiNothing"}}},
+	}
+
+	want := map[string]map[string]string{
+		"apiAccount": {"avatar": "bytes", "user": "apiUser", "version": "bigint"},
+		"apiUser":    {"create_time": "date", "friends": "[]apiUser"},
+	}
+	if got := jsonConversions(definitions, true, true); !reflect.DeepEqual(got, want) {
+		t.Errorf("jsonConversions() = %v, want %v", got, want)
+	}
+	want = map[string]map[string]string{"apiAccount": {"avatar": "bytes", "version": "bigint"}}
+	if got := jsonConversions(definitions, false, true); !reflect.DeepEqual(got, want) {
+		t.Errorf("jsonConversions() without dates = %v, want %v", got, want)
+	}
+	// base64 encoded fields are always converted, since they are always typed Uint8Array.
+	want = map[string]map[string]string{"apiAccount": {"avatar": "bytes"}}
+	if got := jsonConversions(definitions, false, false); !reflect.DeepEqual(got, want) {
+		t.Errorf("jsonConversions() without dates and bigints = %v, want %v", got, want)
+	}
+}
+
+func TestConstraintTags(t *testing.T) {
+	zero, hundred, half := 0.0, 100.0, 0.5
+	three, twenty := 3, 20
+	tests := []struct {
+		constraints Constraints
+		want        string
+	}{
+		{Constraints{}, ""},
+		{Constraints{Minimum: &zero}, "@minimum 0"},
+		{Constraints{Maximum: &hundred}, "@maximum 100"},
+		{Constraints{Minimum: &zero, Maximum: &hundred}, "@minimum 0 @maximum 100"},
+		{Constraints{Minimum: &zero, ExclusiveMinimum: true, Maximum: &half, ExclusiveMaximum: true}, "@exclusiveMinimum 0 @exclusiveMaximum 0.5"},
+		{Constraints{Pattern: "^[a-z0-9]{3,20}$", MaxLength: &twenty}, "@pattern ^[a-z0-9]{3,20}$ @maxLength 20"},
+		{Constraints{MinLength: &three}, "@minLength 3"},
+		// a pattern cannot end the comment it is written into.
+		{Constraints{Pattern: "a*/"}, `@pattern a*\/`},
+	}
+
+	for _, tt := range tests {
+		if got := constraintTags(tt.constraints); got != tt.want {
+			t.Errorf("constraintTags(%+v) = %q, want %q", tt.constraints, got, tt.want)
+		}
+	}
+}
+
+func TestIoTsOrder(t *testing.T) {
+	definitions := map[string]Definition{
+		"apiA": {Properties: map[string]Property{"b": {Ref: "#/definitions/apiB"}}},
+		"apiB": {Properties: map[string]Property{"c": {Type: "array", Items: struct {
+			Type string
+			Ref  string `json:"$ref"`
+		}{Ref: "#/definitions/apiC"}}}},
+		"apiC": {},
+	}
+
+	got := strings.Join(ioTsOrder(definitions), ",")
+	if want := "apiC,apiB,apiA"; got != want {
+		t.Errorf("ioTsOrder() = %q, want %q", got, want)
+	}
+}
+
+func TestIoTsRecursive(t *testing.T) {
+	ref := func(name string) struct {
+		Type string
+		Ref  string `json:"$ref"`
+	} {
+		return struct {
+			Type string
+			Ref  string `json:"$ref"`
+		}{Ref: "#/definitions/" + name}
+	}
+	definitions := map[string]Definition{
+		"apiGroup":   {Properties: map[string]Property{"members": {Type: "array", Items: ref("apiMember")}}},
+		"apiMember":  {Properties: map[string]Property{"group": {Ref: "#/definitions/apiGroup"}, "user": {Ref: "#/definitions/apiUser"}}},
+		"apiThing":   {Properties: map[string]Property{"children": {Type: "array", Items: ref("apiThing")}}},
+		"apiUser":    {},
+		"apiAccount": {Properties: map[string]Property{"user": {Ref: "#/definitions/apiUser"}}},
+	}
+
+	want := map[string]bool{"apiGroup": true, "apiMember": true, "apiThing": true}
+	if got := ioTsRecursive(definitions); !reflect.DeepEqual(got, want) {
+		t.Errorf("ioTsRecursive() = %v, want %v", got, want)
+	}
+}
+
+func TestUnwritableOutputFails(t *testing.T) {
+	input := filepath.Join("testdata", "integer_map.swagger.json")
+
+	t.Run("read-only directory", func(t *testing.T) {
+		if os.Geteuid() == 0 {
+			t.Skip("root can write to read-only directories")
+		}
+		dir := t.TempDir()
+		if err := os.Chmod(dir, 0555); err != nil {
+			t.Fatal(err)
+		}
+		defer os.Chmod(dir, 0755)
+
+		output, err := runGenerator(t, "-output", filepath.Join(dir, "api.gen.ts"), input, "Nakama")
+		if err == nil {
+			t.Fatalf("generator succeeded, want non-zero exit:\n%s", output)
+		}
+	})
+
+	t.Run("full device", func(t *testing.T) {
+		if _, err := os.Stat("/dev/full"); err != nil {
+			t.Skip("/dev/full is not available")
+		}
+
+		output, err := runGenerator(t, "-output", "/dev/full", input, "Nakama")
+		if err == nil {
+			t.Fatalf("generator succeeded, want non-zero exit:\n%s", output)
+		}
+		if _, err := os.Stat("/dev/full"); err != nil {
+			t.Fatalf("generator removed /dev/full: %s", err)
+		}
+	})
+}
+
+func TestValidateSchema(t *testing.T) {
+	schema, err := parseSchema([]byte(`{
+		"paths": {
+			"/v2/user/{id}/{name}": {
+				"get": {"operationId": "Nakama_GetUser", "parameters": [{"name": "id", "in": "path"}]},
+				"put": {"operationId": "Nakama_GetUser", "parameters": [{"name": "id", "in": "path"}, {"name": "name", "in": "path"}, {"name": "body", "in": "body"}]}
+			},
+			"/v2/account": {
+				"get": {"responses": {"200": {"schema": {"$ref": "#/definitions/apiMissing"}}}}
+			}
+		},
+		"definitions": {
+			"apiUser": {"properties": {"friends": {"type": "array", "items": {"$ref": "#/definitions/apiFriend"}}}}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := []string{
+		"GET /v2/account has no operationId",
+		"GET /v2/account response refers to undefined definition #/definitions/apiMissing",
+		"GET /v2/user/{id}/{name} does not declare path parameter name",
+		"PUT /v2/user/{id}/{name} body parameter body has no schema",
+		"operationId Nakama_GetUser is used by GET /v2/user/{id}/{name}, PUT /v2/user/{id}/{name}",
+		"property friends of apiUser refers to undefined definition #/definitions/apiFriend",
+	}
+	if got := validateSchema(schema); !reflect.DeepEqual(got, want) {
+		t.Errorf("validateSchema() = %q, want %q", got, want)
+	}
+}
+
+func TestCompareSchemas(t *testing.T) {
+	old, err := parseSchema([]byte(`{
+		"paths": {
+			"/v2/user/{id}": {
+				"get": {"operationId": "Nakama_GetUser", "parameters": [{"name": "id", "in": "path", "type": "string"}, {"name": "full", "in": "query", "type": "boolean"}]},
+				"delete": {"operationId": "Nakama_DeleteUser", "parameters": [{"name": "id", "in": "path", "type": "string"}]}
+			}
+		},
+		"definitions": {
+			"apiUser": {"properties": {"id": {"type": "string"}, "rank": {"type": "integer", "format": "int32"}}}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := parseSchema([]byte(`{
+		"paths": {
+			"/v2/user/{id}": {
+				"get": {"operationId": "Nakama_GetUser", "parameters": [{"name": "id", "in": "path", "type": "integer"}, {"name": "lang", "in": "query", "type": "string"}, {"name": "token", "in": "query", "required": true, "type": "string"}]},
+				"put": {"operationId": "Nakama_UpdateUser", "parameters": [{"name": "id", "in": "path", "type": "string"}]}
+			}
+		},
+		"definitions": {
+			"apiUser": {"properties": {"id": {"type": "string"}, "rank": {"type": "string", "format": "int64"}, "avatarUrl": {"type": "string"}}}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	breaking, additions := compareSchemas(old, current)
+	wantBreaking := []string{
+		"definition apiUser field rank changed from integer (int32) to string (int64)",
+		"operation Nakama_DeleteUser was removed",
+		"operation Nakama_GetUser has new required parameter token",
+		"operation Nakama_GetUser parameter full was removed",
+		"operation Nakama_GetUser parameter id changed from string to number",
+	}
+	wantAdditions := []string{
+		"definition apiUser field avatarUrl was added",
+		"operation Nakama_GetUser has new parameter lang",
+		"operation Nakama_UpdateUser was added",
+	}
+	if !reflect.DeepEqual(breaking, wantBreaking) {
+		t.Errorf("breaking = %q, want %q", breaking, wantBreaking)
+	}
+	if !reflect.DeepEqual(additions, wantAdditions) {
+		t.Errorf("additions = %q, want %q", additions, wantAdditions)
+	}
+
+	if breaking, _ := compareSchemas(old, old); len(breaking) > 0 {
+		t.Errorf("comparing a specification with itself reported %q", breaking)
+	}
+}
+
+func TestChangelog(t *testing.T) {
+	old, err := parseSchema([]byte(`{
+		"paths": {
+			"/v2/user/{id}": {
+				"get": {"operationId": "Nakama_GetUser", "parameters": [{"name": "id", "in": "path", "type": "string"}]},
+				"delete": {"operationId": "Nakama_DeleteUser", "parameters": [{"name": "id", "in": "path", "type": "string"}]}
+			},
+			"/v2/account": {
+				"get": {"operationId": "Nakama_GetAccount"}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	current, err := parseSchema([]byte(`{
+		"paths": {
+			"/v2/user/{id}": {
+				"get": {"operationId": "Nakama_GetUser", "parameters": [{"name": "id", "in": "path", "type": "string"}, {"name": "lang", "in": "query", "type": "string"}]},
+				"put": {"operationId": "Nakama_UpdateUser", "parameters": [{"name": "id", "in": "path", "type": "string"}]}
+			},
+			"/v2/account": {
+				"get": {"operationId": "Nakama_GetAccount", "deprecated": true}
+			}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := "# Changelog\n\n## [Unreleased]\n" +
+		"\n### Added\n\n- `Nakama_UpdateUser` `PUT /v2/user/{id}`\n" +
+		"\n### Changed\n\n- `Nakama_GetUser` `GET /v2/user/{id}`: has new parameter lang\n" +
+		"\n### Deprecated\n\n- `Nakama_GetAccount` `GET /v2/account`\n" +
+		"\n### Removed\n\n- `Nakama_DeleteUser` `DELETE /v2/user/{id}`\n"
+	if got := changelog(old, current); got != want {
+		t.Errorf("changelog() = %q, want %q", got, want)
+	}
+}
+
+func TestMergeSchema(t *testing.T) {
+	parse := func(content string) Schema {
+		schema, err := parseSchema([]byte(content))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return schema
+	}
+	first := `{"paths": {"/v2/account": {"get": {"operationId": "Nakama_GetAccount"}}}, "definitions": {"apiAccount": {"properties": {"id": {"type": "string"}}}}}`
+	same := `{"paths": {"/v2/user": {"get": {"operationId": "Nakama_GetUser"}}}, "definitions": {"apiAccount": {"properties": {"id": {"type": "string"}}}}}`
+	different := `{"definitions": {"apiAccount": {"properties": {"id": {"type": "string"}, "email": {"type": "string"}}}}}`
+
+	schema := parse(first)
+	if err := mergeSchema(&schema, parse(same), "error"); err != nil {
+		t.Fatalf("merging identical definitions failed: %s", err)
+	}
+	if len(schema.Paths) != 2 {
+		t.Errorf("merged %d paths, want 2", len(schema.Paths))
+	}
+
+	if err := mergeSchema(&schema, parse(different), "error"); err == nil || !strings.Contains(err.Error(), "definition apiAccount is defined differently") {
+		t.Errorf("merging conflicting definitions returned %v", err)
+	}
+
+	for strategy, want := range map[string]int{"first": 1, "last": 2} {
+		schema := parse(first)
+		if err := mergeSchema(&schema, parse(different), strategy); err != nil {
+			t.Fatal(err)
+		}
+		if got := len(schema.Definitions["apiAccount"].Properties); got != want {
+			t.Errorf("%s: apiAccount has %d properties, want %d", strategy, got, want)
+		}
+	}
+}
+
+func TestDefs(t *testing.T) {
+	schema, err := parseSchema([]byte(`{
+		"paths": {"/v2/user": {"get": {"operationId": "Nakama_GetUser", "responses": {"200": {"schema": {"$ref": "#/$defs/apiUser"}}}}}},
+		"definitions": {"apiAccount": {"properties": {"user": {"$ref": "#/$defs/apiUser"}}}},
+		"$defs": {"apiUser": {"properties": {"id": {"type": "string"}}}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := schema.Definitions["apiUser"]; !ok || len(schema.Definitions) != 2 {
+		t.Errorf("definitions = %v, want apiAccount and apiUser", schema.Definitions)
+	}
+	if violations := validateSchema(schema); len(violations) > 0 {
+		t.Errorf("validateSchema() = %q", violations)
+	}
+
+	_, err = parseSchema([]byte(`{
+		"definitions": {"apiUser": {"properties": {"id": {"type": "string"}}}},
+		"$defs": {"apiUser": {"properties": {"id": {"type": "integer"}}}}
+	}`))
+	if err == nil {
+		t.Error("parsing conflicting definitions and $defs succeeded")
+	}
+}
+
+func TestPathItemSse(t *testing.T) {
+	schema, err := parseSchema([]byte(`{
+		"paths": {
+			"/v2/events": {"x-nakama-sse": true, "get": {"operationId": "Nakama_StreamEvents"}},
+			"/v2/account": {"get": {"operationId": "Nakama_GetAccount"}}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !schema.Paths["/v2/events"]["get"].XNakamaSse || len(schema.Paths["/v2/events"]) != 1 {
+		t.Errorf("path item extension is not applied to its operations: %+v", schema.Paths["/v2/events"])
+	}
+	if schema.Paths["/v2/account"]["get"].XNakamaSse {
+		t.Error("operation of another path item streams events")
+	}
+	if got := credentials(schema.Paths["/v2/events"]["get"]); len(got) > 0 {
+		t.Errorf("event stream takes credentials %v, which EventSource cannot send", got)
+	}
+}
+
+func TestComponentsSchemas(t *testing.T) {
+	schema, err := parseSchema([]byte(`{
+		"openapi": "3.0.0",
+		"paths": {"/v2/user": {"get": {"operationId": "Nakama_GetUser", "responses": {"200": {"schema": {"$ref": "#/components/schemas/apiUser"}}}}}},
+		"components": {"schemas": {
+			"apiUser": {"properties": {"id": {"type": "string"}, "friends": {"type": "array", "items": {"$ref": "#/components/schemas/apiFriend"}}}},
+			"apiFriend": {"properties": {"state": {"type": "integer"}}}
+		}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(schema.Definitions) != 2 {
+		t.Errorf("definitions = %v, want apiFriend and apiUser", schema.Definitions)
+	}
+	if violations := validateSchema(schema); len(violations) > 0 {
+		t.Errorf("validateSchema() = %q", violations)
+	}
+	if got := convertRefToClassName("#/components/schemas/apiFriend"); got != "ApiFriend" {
+		t.Errorf("convertRefToClassName() = %q, want %q", got, "ApiFriend")
+	}
+}
+
+func TestRequestBody(t *testing.T) {
+	schema, err := parseSchema([]byte(`{
+		"paths": {"/v2/account": {"put": {
+			"operationId": "Nakama_UpdateAccount",
+			"requestBody": {"required": true, "content": {"application/json": {"schema": {"$ref": "#/components/schemas/apiUpdateAccountRequest"}}}}
+		}}},
+		"components": {"schemas": {"apiUpdateAccountRequest": {"properties": {"username": {"type": "string"}}}}}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parameters := schema.Paths["/v2/account"]["put"].Parameters
+	if len(parameters) != 1 {
+		t.Fatalf("parameters = %+v, want one body parameter", parameters)
+	}
+	body := parameters[0]
+	if body.Name != "body" || body.In != "body" || !body.Required || body.Schema.Ref != "#/components/schemas/apiUpdateAccountRequest" {
+		t.Errorf("body parameter = %+v", body)
+	}
+}
+
+func TestInheritedMediaTypes(t *testing.T) {
+	schema, err := parseSchema([]byte(`{
+		"consumes": ["application/json"],
+		"produces": ["text/plain"],
+		"paths": {
+			"/healthcheck": {"get": {"operationId": "Nakama_Healthcheck"}},
+			"/v2/storage/upload": {"post": {"operationId": "Nakama_Upload", "consumes": ["multipart/form-data"], "produces": ["application/json"]}}
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	healthcheck := schema.Paths["/healthcheck"]["get"]
+	if !reflect.DeepEqual(healthcheck.Consumes, []string{"application/json"}) || !reflect.DeepEqual(healthcheck.Produces, []string{"text/plain"}) {
+		t.Errorf("healthcheck consumes %v and produces %v, want the media types of the specification", healthcheck.Consumes, healthcheck.Produces)
+	}
+	upload := schema.Paths["/v2/storage/upload"]["post"]
+	if !reflect.DeepEqual(upload.Consumes, []string{"multipart/form-data"}) || !reflect.DeepEqual(upload.Produces, []string{"application/json"}) {
+		t.Errorf("upload consumes %v and produces %v, want its own media types", upload.Consumes, upload.Produces)
+	}
+}
+
+func TestXOperationId(t *testing.T) {
+	input := filepath.Join(t.TempDir(), "api.swagger.json")
+	spec := `{
+		"paths": {"/v2/account/authenticate/email": {"post": {
+			"operationId": "NakamaService_AuthenticateEmail",
+			"x-operation-id": "AuthenticateEmail",
+			"summary": "Authenticate a user with an email+password.",
+			"responses": {"200": {"description": "A successful response.", "schema": {"type": "object"}}}
+		}}}
+	}`
+	if err := os.WriteFile(input, []byte(spec), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	got := generateOutput(t, "-emit-react-hooks", input, "Nakama")
+	for _, want := range []string{"  authenticateEmail(bearerToken: string,", "export function useAuthenticateEmail("} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output does not contain %q", want)
+		}
+	}
+	if strings.Contains(got, "nakamaService") {
+		t.Errorf("output is named after the operationId rather than x-operation-id")
+	}
+}
+
+func TestValidSpecs(t *testing.T) {
+	inputs, err := filepath.Glob(filepath.Join("testdata", "*.swagger.json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, input := range inputs {
+		content, err := os.ReadFile(input)
+		if err != nil {
+			t.Fatal(err)
+		}
+		schema, err := parseSchema(content)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if violations := validateSchema(schema); len(violations) > 0 {
+			t.Errorf("%s: %q", input, violations)
+		}
+	}
+}
+
+func TestGuardCheck(t *testing.T) {
+	tests := []struct {
+		property Property
+		want     string
+	}{
+		{Property{Type: "string"}, `typeof obj["id"] === "string"`},
+		{Property{Type: "string", Format: "date-time"}, `(typeof obj["id"] === "string" || obj["id"] instanceof Date)`},
+		{Property{Type: "integer", Format: "int32"}, `typeof obj["id"] === "number"`},
+		{Property{Type: "string", Format: "int64"}, `(typeof obj["id"] === "string" || typeof obj["id"] === "number" || typeof obj["id"] === "bigint")`},
+		{Property{Type: "array"}, `Array.isArray(obj["id"])`},
+		{Property{Ref: "#/definitions/apiUser"}, ""},
+	}
+	for _, tt := range tests {
+		if got := guardCheck(tt.property, "id"); got != tt.want {
+			t.Errorf("guardCheck(%+v) = %q, want %q", tt.property, got, tt.want)
+		}
+	}
+}
+
+func TestTitle(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"", ""},
+		{"apiAccount", "ApiAccount"},
+		{"ApiAccount", "ApiAccount"},
+		{"élanRecord", "ÉlanRecord"},
+		{"ǆemal", "ǅemal"},
+	}
+
+	for _, tt := range tests {
+		if got := title(tt.input); got != tt.want {
+			t.Errorf("title(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+
+	if got, want := convertRefToClassName("#/definitions/élanRecord"), "ÉlanRecord"; got != want {
+		t.Errorf("convertRefToClassName() = %q, want %q", got, want)
+	}
+}
+
+// TestSnakeToCamel pins the current output of snakeToCamel, got, as generated
+// method names depend on it. Where it differs from the intended output, want,
+// the row is a known bug which changes the generated names once fixed.
+func TestSnakeToCamel(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+		got   string
+	}{
+		{"", "", ""},
+		{"foo", "foo", "foo"},
+		{"fooBar", "fooBar", "fooBar"},
+		{"foo_bar_baz", "fooBarBaz", "fooBarBaz"},
+		{"2fa_token", "2faToken", "2faToken"},
+		// a leading underscore is kept, as the first character is never an underscore separator.
+		{"_foo", "_foo", "_foo"},
+		{"authenticate_email", "authenticateEmail", "authenticateEmail"},
+		// known bug: only the first letter is lower-cased, the rest is kept as is.
+		{"FOO", "foo", "fOO"},
+		// known bug: a trailing underscore is dropped.
+		{"foo_", "foo_", "foo"},
+		// known bug: of two underscores, the second is kept instead of upper-casing the next letter.
+		{"foo__bar", "fooBar", "foo_bar"},
+		// known bug: the first byte rather than the first rune is lower-cased, which mangles a multibyte letter.
+		{"Émoji_name", "émojiName", "ãmojiName"},
+	}
+
+	for _, tt := range tests {
+		got := snakeToCamel(tt.input)
+		if got != tt.got {
+			t.Errorf("snakeToCamel(%q) = %q, want %q", tt.input, got, tt.got)
+		}
+		if got != tt.want {
+			t.Logf("known bug: snakeToCamel(%q) = %q, intended %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func FuzzParse(f *testing.F) {
+	specs, err := filepath.Glob(filepath.Join("testdata", "*.swagger.json"))
+	if err != nil {
+		f.Fatal(err)
+	}
+	for _, spec := range specs {
+		content, err := os.ReadFile(spec)
+		if err != nil {
+			f.Fatal(err)
+		}
+		f.Add(content)
+	}
+	f.Add([]byte(`{}`))
+	f.Add([]byte(`{"definitions": {}}`))
+	f.Add([]byte(`{"paths": {}, "definitions": null}`))
+	f.Add([]byte(`{"paths": {"/v2/x": {"get": {}}}}`))
+
+	f.Fuzz(func(t *testing.T, content []byte) {
+		// errors are expected for malformed specs, panics are not.
+		render(t, content)
+	})
+}
+
+// render parses a spec and renders the API client with the default options,
+// discarding the output.
+func render(tb testing.TB, content []byte) error {
+	tb.Helper()
+
+	schema, err := parseSchema(content)
+	if err != nil {
+		return err
+	}
+	schema.Namespace = "Nakama"
+	schema.Indent = "  "
+
+	tmpl, err := template.New("api").Funcs(funcMap(&schema)).Parse(indentTemplate(codeTemplate))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return tmpl.Execute(io.Discard, schema)
+}
+
+// largeSpec returns the test API with its paths, operations and definitions
+// copied the given number of times, renaming each copy and the references
+// between its definitions.
+func largeSpec(tb testing.TB, copies int) []byte {
+	tb.Helper()
+
+	content, err := os.ReadFile(filepath.Join("testdata", "api.swagger.json"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	var spec map[string]json.RawMessage
+	if err := json.Unmarshal(content, &spec); err != nil {
+		tb.Fatal(err)
+	}
+	var paths, definitions map[string]json.RawMessage
+	if err := json.Unmarshal(spec["paths"], &paths); err != nil {
+		tb.Fatal(err)
+	}
+	if err := json.Unmarshal(spec["definitions"], &definitions); err != nil {
+		tb.Fatal(err)
+	}
+
+	copiedPaths := make(map[string]json.RawMessage)
+	copiedDefinitions := make(map[string]json.RawMessage)
+	for i := 0; i < copies; i++ {
+		rename := strings.NewReplacer(`"#/definitions/`, fmt.Sprintf(`"#/definitions/copy%d`, i), `"Nakama_`, fmt.Sprintf(`"Nakama_Copy%d`, i))
+		for url, path := range paths {
+			copiedPaths[fmt.Sprintf("/copy%d%s", i, url)] = json.RawMessage(rename.Replace(string(path)))
+		}
+		for name, definition := range definitions {
+			copiedDefinitions[fmt.Sprintf("copy%d%s", i, name)] = json.RawMessage(rename.Replace(string(definition)))
+		}
+	}
+
+	for key, value := range map[string]interface{}{"paths": copiedPaths, "definitions": copiedDefinitions} {
+		if spec[key], err = json.Marshal(value); err != nil {
+			tb.Fatal(err)
+		}
+	}
+	content, err = json.Marshal(spec)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	return content
+}
+
+// BenchmarkGenerate renders the Nakama API from testdata/nakama.swagger.json,
+// or when it is missing a spec of its size, with 150 operations and as many
+// definitions copied from the test API.
+func BenchmarkGenerate(b *testing.B) {
+	content, err := os.ReadFile(filepath.Join("testdata", "nakama.swagger.json"))
+	if errors.Is(err, os.ErrNotExist) {
+		b.Log("testdata/nakama.swagger.json is missing, rendering copies of the test API")
+		content = largeSpec(b, 15)
+	} else if err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		if err := render(b, content); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// taggedSchema returns the test API with its operations copied under the
+// given number of tags.
+func taggedSchema(tb testing.TB, tags int) Schema {
+	tb.Helper()
+
+	content, err := os.ReadFile(filepath.Join("testdata", "api.swagger.json"))
+	if err != nil {
+		tb.Fatal(err)
+	}
+	schema, err := parseSchema(content)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	schema.Namespace = "Nakama"
+	schema.Indent = "  "
+
+	paths := make(map[string]map[string]Operation)
+	for i := 0; i < tags; i++ {
+		for url, path := range schema.Paths {
+			tagged := make(map[string]Operation)
+			for method, operation := range path {
+				operation.Tags = []string{fmt.Sprintf("Tag%d", i)}
+				tagged[method] = operation
+			}
+			paths[fmt.Sprintf("/tag%d%s", i, url)] = tagged
+		}
+	}
+	schema.Paths = paths
+	return schema
+}
+
+func TestWriteSplitByTag(t *testing.T) {
+	schema := taggedSchema(t, 8)
+	tmpl := template.Must(template.New("api").Funcs(funcMap(&schema)).Parse(indentTemplate(codeTemplate)))
+
+	sequential, parallel := t.TempDir(), t.TempDir()
+	if err := writeSplitByTag(context.Background(), sequential, tmpl, schema, 1, nil); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeSplitByTag(context.Background(), parallel, tmpl, schema, 4, nil); err != nil {
+		t.Fatal(err)
+	}
+
+	files, err := os.ReadDir(sequential)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) != 10 {
+		t.Errorf("wrote %d files, want definitions.ts, index.ts and 8 tags", len(files))
+	}
+	for _, file := range files {
+		want, _ := os.ReadFile(filepath.Join(sequential, file.Name()))
+		got, err := os.ReadFile(filepath.Join(parallel, file.Name()))
+		if err != nil || string(got) != string(want) {
+			t.Errorf("%s differs between 1 and 4 workers", file.Name())
+		}
+	}
+
+	// Directories in the way of two of the files fail both workers.
+	blocked := t.TempDir()
+	for _, name := range []string{"tag1.ts", "tag5.ts"} {
+		if err := os.Mkdir(filepath.Join(blocked, name), 0755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	err = writeSplitByTag(context.Background(), blocked, tmpl, schema, 4, nil)
+	if err == nil || !strings.Contains(err.Error(), "tag1.ts") || !strings.Contains(err.Error(), "tag5.ts") {
+		t.Errorf("error %v does not name both failed files", err)
+	}
+	if _, err := os.Stat(filepath.Join(blocked, "tag7.ts")); err != nil {
+		t.Errorf("a failed file stopped the other workers: %s", err)
+	}
+}
+
+func TestSplitByTagGolden(t *testing.T) {
+	tests := []struct {
+		fixture string
+		flags   []string
+		prefix  string
+		files   []string
+	}{
+		{"tags", nil, "tags.split", []string{"definitions", "account", "leaderboard", "default", "index"}},
+		// the features emitting code next to each API class, with files which have no offline-safe operations.
+		{"offline_queue", []string{"-emit-offline-queue", "-emit-react-hooks", "-emit-mock", "-emit-type-guards", "-emit-dedup"}, "offline_queue.split", []string{"definitions", "account", "rpc", "default", "index"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.prefix, func(t *testing.T) {
+			dir := t.TempDir()
+			args := append(tt.flags, "-split-by-tag", "-output-dir", dir, filepath.Join("testdata", tt.fixture+".swagger.json"), "Nakama")
+			output, err := runGenerator(t, args...)
+			if err != nil {
+				t.Fatalf("generator failed: %s\n%s", err, output)
+			}
+
+			for _, name := range tt.files {
+				got, err := os.ReadFile(filepath.Join(dir, name+".ts"))
+				if err != nil {
+					t.Fatal(err)
+				}
+				assertGolden(t, string(got), tt.prefix+"."+name+".ts.golden")
+			}
+		})
+	}
+}
+
+func TestSplitByTagCollision(t *testing.T) {
+	tmpl := template.Must(template.New("api").Funcs(funcMap(&Schema{})).Parse(indentTemplate(codeTemplate)))
+	for _, tags := range [][]string{{"Leaderboard Records", "leaderboard_records"}, {"Index"}, {"Definitions"}} {
+		paths := make(map[string]map[string]Operation)
+		for i, tag := range tags {
+			paths[fmt.Sprintf("/v2/op%d", i)] = map[string]Operation{"get": {OperationId: fmt.Sprintf("Nakama_Op%d", i), Tags: []string{tag}}}
+		}
+
+		dir := t.TempDir()
+		err := writeSplitByTag(context.Background(), dir, tmpl, Schema{Namespace: "Nakama", Paths: paths}, 1, nil)
+		if err == nil || !strings.Contains(err.Error(), "would overwrite") {
+			t.Errorf("tags %q are written with error %v, want a collision", tags, err)
+		}
+		if files, _ := os.ReadDir(dir); len(files) > 0 {
+			t.Errorf("tags %q wrote %d files before failing", tags, len(files))
+		}
+	}
+}
+
+func TestSplitByTagImports(t *testing.T) {
+	tsconfig := filepath.Join(t.TempDir(), "tsconfig.json")
+	if err := os.WriteFile(tsconfig, []byte("{\n  // Required by esbuild.\n  \"compilerOptions\": {\"isolatedModules\": true,},\n}\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		args   []string
+		events string
+	}{
+		{nil, "import { encode } from 'js-base64';\nimport { ApiRpc, ConfigurationParameters, EventStream, defaultConfiguration } from './definitions';\n\n"},
+		{[]string{"-import-type"}, "import { EventStream, defaultConfiguration } from './definitions';\nimport type { ApiRpc, ConfigurationParameters } from './definitions';\n\n"},
+		{[]string{"-tsconfig", tsconfig}, "import { EventStream, defaultConfiguration } from './definitions';\nimport type { ApiRpc, ConfigurationParameters } from './definitions';\n\n"},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		args := append(tt.args, "-split-by-tag", "-output-dir", dir, filepath.Join("testdata", "api.swagger.json"), "Nakama")
+		output, err := runGenerator(t, args...)
+		if err != nil {
+			t.Fatalf("generator failed: %s\n%s", err, output)
+		}
+
+		content, err := os.ReadFile(filepath.Join(dir, "events.ts"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !strings.Contains(string(content), tt.events) {
+			t.Errorf("events.ts generated with %v does not contain %q", tt.args, tt.events)
+		}
+	}
+}
+
+func TestStripJsonComments(t *testing.T) {
+	content := `{
+  /* Paths such as "@/*" are not comments. */
+  "compilerOptions": {
+    "paths": {"@/*": ["./src/*"]}, // nor is "//" in a string
+    "baseUrl": "http://example.com",
+  },
+}`
+	var tsconfig map[string]interface{}
+	if err := json.Unmarshal(stripJsonComments([]byte(content)), &tsconfig); err != nil {
+		t.Fatalf("%s\n%s", err, stripJsonComments([]byte(content)))
+	}
+	options := tsconfig["compilerOptions"].(map[string]interface{})
+	if options["baseUrl"] != "http://example.com" || options["paths"].(map[string]interface{})["@/*"] == nil {
+		t.Errorf("unexpected compilerOptions %v", options)
+	}
+}
+
+func TestImportSymbols(t *testing.T) {
+	symbols := exportedSymbols("export interface ApiUser {\n}\nexport const enum Gender {\n}\nexport function base64ToUint8Array(value: string) {\n}\nconst local = 1;\n")
+	if want := map[string]bool{"ApiUser": true, "Gender": false, "base64ToUint8Array": false}; !reflect.DeepEqual(symbols, want) {
+		t.Errorf("exportedSymbols() = %v, want %v", symbols, want)
+	}
+
+	code := "const user: ApiUser = {gender: Gender.MALE};\nconst ApiUserList = [];\n"
+	if got, want := importSymbols(code, symbols, "./definitions", false), "import { ApiUser, Gender } from './definitions';\n"; got != want {
+		t.Errorf("importSymbols() = %q, want %q", got, want)
+	}
+	if got, want := importSymbols(code, symbols, "./definitions", true), "import { Gender } from './definitions';\nimport type { ApiUser } from './definitions';\n"; got != want {
+		t.Errorf("importSymbols() with importType = %q, want %q", got, want)
+	}
+
+	// names in comments and strings are not imported, those in template expressions are.
+	code = "/** Returns an ApiUser. */\n// Gender\nconst s = \"ApiUser\" + 'Gender \\' ApiUser';\nconst t = `ApiUser ${f({a: ApiUser})} Gender`;\n"
+	if got, want := importSymbols(code, symbols, "./definitions", false), "import { ApiUser } from './definitions';\n"; got != want {
+		t.Errorf("importSymbols() of comments and strings = %q, want %q", got, want)
+	}
+	code = "const t = `${`${Gender}`}`; // ApiUser\n"
+	if got, want := importSymbols(code, symbols, "./definitions", false), "import { Gender } from './definitions';\n"; got != want {
+		t.Errorf("importSymbols() of nested template literals = %q, want %q", got, want)
+	}
+}
+
+// BenchmarkSplitByTag renders a spec with 50 tags sequentially and with a
+// worker per CPU.
+func BenchmarkSplitByTag(b *testing.B) {
+	schema := taggedSchema(b, 50)
+	tmpl := template.Must(template.New("api").Funcs(funcMap(&schema)).Parse(indentTemplate(codeTemplate)))
+
+	for name, workers := range map[string]int{"sequential": 1, "parallel": runtime.NumCPU()} {
+		b.Run(name, func(b *testing.B) {
+			dir := b.TempDir()
+			b.ReportAllocs()
+			for i := 0; i < b.N; i++ {
+				if err := writeSplitByTag(context.Background(), dir, tmpl, schema, workers, nil); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkSnakeToCamel(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		snakeToCamel("list_leaderboard_records_around_owner")
+	}
+}
+
+func TestEscapeReserved(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"type", "type_"},
+		{"class", "class_"},
+		{"delete", "delete_"},
+		{"export", "export_"},
+		{"userId", "userId"},
+		// keywords are case-sensitive.
+		{"Class", "Class"},
+	}
+
+	for _, tt := range tests {
+		if got := escapeReserved(tt.input); got != tt.want {
+			t.Errorf("escapeReserved(%q) = %q, want %q", tt.input, got, tt.want)
+		}
+	}
+}
+
+func TestGenerateFromURL(t *testing.T) {
+	server := httptest.NewServer(http.FileServer(http.Dir("testdata")))
+	defer server.Close()
+
+	got := generateOutput(t, server.URL+"/integer_map.swagger.json", "Nakama")
+	assertGolden(t, got, "integer_map.ts.golden")
+}